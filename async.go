@@ -0,0 +1,37 @@
+package xreq
+
+// Future is the pending Response of an asynchronous request.
+type Future struct {
+	done chan struct{}
+	resp *Response
+	err  error
+}
+
+// DoAsync method construct a HTTP request with options
+// and send it in a new goroutine, see Client.DoAsync.
+func DoAsync(url string, opt ...Option) *Future {
+	return defaultClient.DoAsync(url, opt...)
+}
+
+// DoAsync method construct a HTTP request with options
+// and send it in a new goroutine, the Response of the Future
+// must be consumed or closed as the one of DoR.
+func (c *Client) DoAsync(url string, opt ...Option) *Future {
+	f := &Future{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		f.resp, f.err = c.DoR(url, opt...)
+	}()
+	return f
+}
+
+// Done return a channel that's closed when the request is finished.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Result waits for the request and return its Response and error.
+func (f *Future) Result() (*Response, error) {
+	<-f.done
+	return f.resp, f.err
+}
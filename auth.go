@@ -0,0 +1,165 @@
+package xreq
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// BasicAuth defines the username and password of the HTTP Basic Authentication.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// WithBasicAuth set the Authorization header
+// with the HTTP Basic Authentication.
+func WithBasicAuth(username, password string) Option {
+	return func(o *Options) {
+		o.Request.SetBasicAuth(username, password)
+	}
+}
+
+// WithBearerToken set the Authorization header with the bearer token.
+func WithBearerToken(token string) Option {
+	return func(o *Options) {
+		o.Request.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// WithReauth set the callback to refresh the credential,
+// when the request gets 401, the callback is invoked to obtain
+// a fresh bearer token and the request is retried once with it.
+// NOTE the request body is replayed via GetBody, a request whose body
+// can't be replayed is not retried.
+func WithReauth(refresh func(ctx context.Context) (string, error)) Option {
+	return func(o *Options) {
+		o.reauth = refresh
+	}
+}
+
+// reauth refresh the credential and resend the request of opts if resp
+// is 401. opts.Request is the last attempt, so the resend goes to the host
+// which answered 401, with the signers, the retry and the AuthProvider.
+func (c *Client) reauth(hc *http.Client, opts *Options, resp *http.Response) (*http.Response, error) {
+	req := opts.Request
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+
+	token, err := opts.reauth(req.Context())
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("reauth error: %w", err)
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, fmt.Errorf("get body error: %w", err)
+		}
+	}
+	// the credential is set before signing, so it can be signed.
+	retry.Header.Set("Authorization", "Bearer "+token)
+	if err = opts.sign(retry); err != nil {
+		return nil, err
+	}
+	opts.Request = retry
+	return c.sendRetry(hc, opts)
+}
+
+// AuthProvider performs a challenge-response authentication,
+// such as NTLM or Negotiate.
+type AuthProvider interface {
+	// Scheme return the authentication scheme, e.g. "NTLM".
+	Scheme() string
+	// Negotiate return the token of the initial request.
+	Negotiate() ([]byte, error)
+	// Challenge return the token in response to the server challenge.
+	Challenge(challenge []byte) ([]byte, error)
+}
+
+// WithAuthProvider set the challenge-response authentication of the request,
+// it overrides the Config.AuthProvider of the Client.
+// NOTE the request body is sent twice during the handshake,
+// so it must be replayable via GetBody.
+func WithAuthProvider(p AuthProvider) Option {
+	return func(o *Options) {
+		o.authProvider = p
+	}
+}
+
+// authenticate send req with the handshake of p.
+func authenticate(hc *http.Client, req *http.Request, p AuthProvider) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return nil, fmt.Errorf("%s authentication requires replayable body", p.Scheme())
+	}
+
+	token, err := p.Negotiate()
+	if err != nil {
+		return nil, fmt.Errorf("%s negotiate error: %w", p.Scheme(), err)
+	}
+	req.Header.Set("Authorization", p.Scheme()+" "+base64.StdEncoding.EncodeToString(token))
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, ok := authChallenge(resp, p.Scheme())
+	if resp.StatusCode != http.StatusUnauthorized || !ok {
+		return resp, nil
+	}
+	// drain the body to reuse the connection,
+	// NTLM authenticates the connection instead of the request.
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	if token, err = p.Challenge(challenge); err != nil {
+		return nil, fmt.Errorf("%s challenge error: %w", p.Scheme(), err)
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, fmt.Errorf("get body error: %w", err)
+		}
+	}
+	retry.Header.Set("Authorization", p.Scheme()+" "+base64.StdEncoding.EncodeToString(token))
+	return hc.Do(retry)
+}
+
+// authChallenge return the decoded challenge of scheme in WWW-Authenticate.
+func authChallenge(resp *http.Response, scheme string) ([]byte, bool) {
+	for _, v := range resp.Header.Values("WWW-Authenticate") {
+		fields := strings.Fields(v)
+		if len(fields) != 2 || !strings.EqualFold(fields[0], scheme) {
+			continue
+		}
+		challenge, err := base64.StdEncoding.DecodeString(fields[1])
+		if err == nil {
+			return challenge, true
+		}
+	}
+	return nil, false
+}
+
+// setDefaultAuth set the client-level credential into req,
+// the Authorization set by options takes precedence.
+func (c *Client) setDefaultAuth(req *http.Request) {
+	if req.Header.Get("Authorization") != "" {
+		return
+	}
+	if c.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.BearerToken)
+	} else if auth := c.config.BasicAuth; auth != nil {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+}
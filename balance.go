@@ -0,0 +1,159 @@
+package xreq
+
+import (
+	"math/rand"
+	urlpkg "net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Canary routes a percentage of the requests of the BaseURLs
+// to an alternate base URL, such as a new version of the backend.
+type Canary struct {
+	URL string
+	// Percent is the percentage of the requests to the URL, from 0 to 100.
+	Percent float64
+}
+
+// Balancer picks the endpoint of each request among the Config.BaseURLs.
+type Balancer interface {
+	// Pick return one of the endpoints, which are the healthy ones of the
+	// BaseURLs, and done which is called once the request is finished.
+	Pick(endpoints []string) (endpoint string, done func())
+}
+
+// NewRoundRobin return a Balancer picks the endpoints in turn,
+// it's the default Balancer.
+func NewRoundRobin() Balancer {
+	return &roundRobin{}
+}
+
+type roundRobin struct {
+	n uint32
+}
+
+func (r *roundRobin) Pick(endpoints []string) (string, func()) {
+	n := atomic.AddUint32(&r.n, 1)
+	return endpoints[(n-1)%uint32(len(endpoints))], func() {}
+}
+
+// NewRandom return a Balancer picks the endpoints randomly.
+func NewRandom() Balancer {
+	return random{}
+}
+
+type random struct{}
+
+func (random) Pick(endpoints []string) (string, func()) {
+	return endpoints[rand.Intn(len(endpoints))], func() {}
+}
+
+// NewLeastInflight return a Balancer picks the endpoint
+// with the least requests in flight.
+func NewLeastInflight() Balancer {
+	return &leastInflight{inflight: make(map[string]int)}
+}
+
+type leastInflight struct {
+	mu       sync.Mutex
+	inflight map[string]int
+	n        int
+}
+
+func (l *leastInflight) Pick(endpoints []string) (string, func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// start from a rotating offset to spread the ties.
+	l.n++
+	best := endpoints[l.n%len(endpoints)]
+	for i := range endpoints {
+		e := endpoints[(l.n+i)%len(endpoints)]
+		if l.inflight[e] < l.inflight[best] {
+			best = e
+		}
+	}
+	l.inflight[best]++
+	var once sync.Once
+	return best, func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.inflight[best]--
+			l.mu.Unlock()
+		})
+	}
+}
+
+// NewWeighted return a Balancer picks the endpoints in proportion to
+// the weights by the smooth weighted round-robin, the endpoints
+// absent from the weights have the weight 1.
+func NewWeighted(weights map[string]int) Balancer {
+	return &weighted{weights: weights, current: make(map[string]int)}
+}
+
+type weighted struct {
+	mu      sync.Mutex
+	weights map[string]int
+	current map[string]int
+}
+
+func (w *weighted) Pick(endpoints []string) (string, func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var best string
+	total := 0
+	for _, e := range endpoints {
+		weight, ok := w.weights[e]
+		if !ok {
+			weight = 1
+		}
+		total += weight
+		w.current[e] += weight
+		if best == "" || w.current[e] > w.current[best] {
+			best = e
+		}
+	}
+	w.current[best] -= total
+	return best, func() {}
+}
+
+// resolveBaseURL joins url to one of the Config.BaseURLs picked by the
+// Balancer or the Config.Canary if url is relative, such as "/users",
+// opts.picked must be called once the request is finished.
+func (c *Client) resolveBaseURL(opts *Options, url string) string {
+	if u, err := urlpkg.Parse(url); err != nil || u.IsAbs() && u.Host != "" {
+		return url
+	}
+	urls := c.baseURLs()
+	if len(urls) == 0 {
+		return url
+	}
+	if canary := c.config.Canary; canary != nil && rand.Float64()*100 < canary.Percent {
+		opts.canary = true
+		return joinBaseURL(canary.URL, url)
+	}
+	if c.health != nil {
+		urls = c.health.healthy(urls)
+	}
+	base, done := c.config.Balancer.Pick(urls)
+	opts.picked = done
+	return joinBaseURL(base, url)
+}
+
+// baseURLs return the discovered endpoints if any, or the Config.BaseURLs.
+func (c *Client) baseURLs() []string {
+	if c.discovery != nil {
+		return c.discovery.endpoints()
+	}
+	return c.config.BaseURLs
+}
+
+// joinBaseURL joins base and the relative url with a single slash.
+func joinBaseURL(base, url string) string {
+	if url == "" || strings.HasPrefix(url, "?") {
+		return base + url
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(url, "/")
+}
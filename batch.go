@@ -0,0 +1,65 @@
+package xreq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Request is a request of the Batch.
+type Request struct {
+	URL     string
+	Options []Option
+}
+
+// BatchResult is the result of a request of the Batch, as DoBytes return.
+type BatchResult struct {
+	Data       []byte
+	StatusCode int
+	Err        error
+}
+
+// Batch executes the requests with the default client, see Client.Batch.
+func Batch(ctx context.Context, concurrency int, requests []Request) ([]BatchResult, error) {
+	return defaultClient.Batch(ctx, concurrency, requests)
+}
+
+// Batch executes the requests with at most concurrency requests in parallel,
+// it return the results in the order of the requests and the errors of them
+// joined, the requests not started yet fail once ctx is done.
+func (c *Client) Batch(ctx context.Context, concurrency int, requests []Request) ([]BatchResult, error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	results := make([]BatchResult, len(requests))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range requests {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			opt := append([]Option{WithContext(ctx)}, requests[i].Options...)
+			r := &results[i]
+			r.Data, r.StatusCode, r.Err = c.DoBytes(requests[i].URL, opt...)
+		}(i)
+	}
+	wg.Wait()
+
+	var errs []error
+	for i, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("request %d: %w", i, r.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,596 @@
+package xreq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores the cached responses of the Client, see Config.Cache.
+type Cache interface {
+	// Get return the entry of key.
+	Get(key string) ([]byte, bool)
+	// Set store the entry of key.
+	Set(key string, entry []byte)
+	// Delete remove the entry of key.
+	Delete(key string)
+}
+
+// MemoryCache is an in-memory Cache, it's safe for concurrent use.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string][]byte
+}
+
+// NewMemoryCache return an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string][]byte)}
+}
+
+// Get implements Cache.
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[key]
+	return entry, ok
+}
+
+// Set implements Cache.
+func (c *MemoryCache) Set(key string, entry []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+}
+
+// Delete implements Cache.
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// cacheableStatus is the status codes cacheable by default, RFC 7231 6.1.
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// cacheEntry is the stored form of a cached response.
+type cacheEntry struct {
+	// Vary holds the request header values of the fields in Vary.
+	Vary         map[string]string
+	RequestTime  time.Time
+	ResponseTime time.Time
+	// Response is the response in wire format.
+	Response []byte
+	// TTL is the freshness lifetime forced by WithCacheTTL.
+	TTL time.Duration
+}
+
+// cacheDirective is the per-request cache control set by options.
+type cacheDirective struct {
+	noCache      bool
+	onlyIfCached bool
+	ttl          time.Duration
+}
+
+type cacheDirectiveKey struct{}
+
+func cacheDirectiveFromContext(ctx context.Context) cacheDirective {
+	d, _ := ctx.Value(cacheDirectiveKey{}).(cacheDirective)
+	return d
+}
+
+// WithNoCache bypass the client cache, the response is neither
+// served from nor stored into the cache.
+func WithNoCache() Option {
+	return func(o *Options) {
+		o.cacheDirective.noCache = true
+	}
+}
+
+// WithCacheTTL force the response to be cached and served for d,
+// regardless of its Cache-Control and Expires.
+func WithCacheTTL(d time.Duration) Option {
+	return func(o *Options) {
+		o.cacheDirective.ttl = d
+	}
+}
+
+// WithOnlyIfCached restrict the request to the client cache,
+// the cached response is served even if it's stale,
+// and 504 Gateway Timeout is returned if nothing is cached.
+func WithOnlyIfCached() Option {
+	return func(o *Options) {
+		o.cacheDirective.onlyIfCached = true
+	}
+}
+
+func gatewayTimeout(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:     "504 Gateway Timeout",
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Body:       http.NoBody,
+		Request:    req,
+	}
+}
+
+// cacheTransport is a private RFC 7234 cache in front of the base transport.
+type cacheTransport struct {
+	base  http.RoundTripper
+	cache Cache
+	// etagOnly disables the freshness, the entries are always revalidated
+	// by ETag or Last-Modified, see Config.ETagCache.
+	etagOnly bool
+
+	// refreshing holds the keys being revalidated in background.
+	refreshing sync.Map
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := cacheKey(req)
+	if req.Method != http.MethodGet {
+		resp, err := t.base.RoundTrip(req)
+		if err == nil && isUnsafeMethod(req.Method) && resp.StatusCode < 400 {
+			t.cache.Delete(key)
+		}
+		return resp, err
+	}
+
+	reqCC := parseCacheControl(req.Header)
+	directive := cacheDirectiveFromContext(req.Context())
+	if directive.noCache || reqCC.has("no-store") || hasConditional(req.Header) {
+		return t.base.RoundTrip(req)
+	}
+
+	entry, cached := t.load(key, req)
+	if directive.onlyIfCached {
+		if !cached {
+			return gatewayTimeout(req), nil
+		}
+		return entry.response(req)
+	}
+	if !cached {
+		return t.fetch(key, req)
+	}
+
+	if t.etagOnly {
+		return t.revalidate(key, req, entry)
+	}
+
+	staleness, respCC := entry.staleness(req.Header, time.Now(), directive.ttl)
+	if staleness < 0 {
+		return entry.response(req)
+	}
+	// RFC 5861, serve the stale entry and revalidate it in background.
+	if d, ok := respCC.seconds("stale-while-revalidate"); ok && staleness < d {
+		t.revalidateBackground(key, req, entry)
+		return entry.response(req)
+	}
+
+	resp, err := t.revalidate(key, req, entry)
+	if err == nil && resp.StatusCode < 500 {
+		return resp, nil
+	}
+	// RFC 5861, serve the stale entry if the origin errors.
+	d, ok := reqCC.seconds("stale-if-error")
+	if !ok {
+		d, ok = respCC.seconds("stale-if-error")
+	}
+	if !ok || staleness >= d {
+		return resp, err
+	}
+	if resp != nil {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	return entry.response(req)
+}
+
+// CloseIdleConnections closes the idle connections of the base transport.
+func (t *cacheTransport) CloseIdleConnections() {
+	closeIdleConnections(t.base)
+}
+
+// revalidateBackground revalidates a copy of the entry in a goroutine,
+// it's deduplicated by key and detached from the context of req,
+// only the cache directive and the route are carried over so the
+// trace hooks of req never fire after it returns.
+func (t *cacheTransport) revalidateBackground(key string, req *http.Request, entry *cacheEntry) {
+	if _, loaded := t.refreshing.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	ctx := context.WithValue(context.Background(), cacheDirectiveKey{}, cacheDirectiveFromContext(req.Context()))
+	if route := RouteFromContext(req.Context()); route != "" {
+		ctx = context.WithValue(ctx, routeKey{}, route)
+	}
+	breq := req.Clone(ctx)
+	bentry := *entry
+	go func() {
+		defer t.refreshing.Delete(key)
+		resp, err := t.revalidate(key, breq, &bentry)
+		if err != nil {
+			return
+		}
+		// read to EOF to store the entry.
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+}
+
+// fetch sends req and stores the response if it's cacheable.
+func (t *cacheTransport) fetch(key string, req *http.Request) (*http.Response, error) {
+	reqTime := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.store(key, req, resp, reqTime)
+	return resp, nil
+}
+
+// revalidate sends req with the validators of entry,
+// on 304 the entry is refreshed and served.
+func (t *cacheTransport) revalidate(key string, req *http.Request, entry *cacheEntry) (*http.Response, error) {
+	cached, err := entry.response(req)
+	if err != nil {
+		t.cache.Delete(key)
+		return t.fetch(key, req)
+	}
+
+	etag, lastModified := cached.Header.Get("ETag"), cached.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		cached.Body.Close()
+		return t.fetch(key, req)
+	}
+	creq := req.Clone(req.Context())
+	if etag != "" {
+		creq.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		creq.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	reqTime := time.Now()
+	resp, err := t.base.RoundTrip(creq)
+	if err != nil {
+		cached.Body.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusNotModified {
+		cached.Body.Close()
+		t.store(key, req, resp, reqTime)
+		return resp, nil
+	}
+
+	resp.Body.Close()
+	for k, vs := range resp.Header {
+		switch k {
+		case "Content-Length", "Transfer-Encoding", "Content-Encoding":
+			continue
+		}
+		cached.Header[k] = vs
+	}
+	cached.Header.Del("X-From-Cache")
+	entry.RequestTime, entry.ResponseTime = reqTime, time.Now()
+	if data, err := encodeResponse(cached); err == nil {
+		entry.Response = data
+		t.save(key, entry)
+		cached.Body.Close()
+		return entry.response(req)
+	}
+	return cached, nil
+}
+
+// store tees the body of resp into the cache if it's cacheable,
+// the entry is saved once the body is read to EOF.
+func (t *cacheTransport) store(key string, req *http.Request, resp *http.Response, reqTime time.Time) {
+	ttl := cacheDirectiveFromContext(req.Context()).ttl
+	if t.etagOnly {
+		ttl = 0
+	}
+	respCC := parseCacheControl(resp.Header)
+	if !cacheableStatus[resp.StatusCode] || resp.Header.Get("Vary") == "*" {
+		return
+	}
+	if ttl == 0 && (respCC.has("no-store") || !hasFreshnessInfo(resp.Header, respCC)) {
+		return
+	}
+	if t.etagOnly && (resp.StatusCode != http.StatusOK ||
+		resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "") {
+		return
+	}
+
+	entry := &cacheEntry{
+		Vary:         varyValues(req.Header, resp.Header),
+		RequestTime:  reqTime,
+		ResponseTime: time.Now(),
+		TTL:          ttl,
+	}
+	// snapshot the response now, the client decodes the body and strips
+	// the Content-Encoding from the header before the body reaches EOF.
+	snapshot := http.Response{
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		Proto:      resp.Proto,
+		ProtoMajor: resp.ProtoMajor,
+		ProtoMinor: resp.ProtoMinor,
+		Header:     resp.Header.Clone(),
+		Request:    resp.Request,
+	}
+	resp.Body = &cachingBody{
+		ReadCloser: resp.Body,
+		onEOF: func(body []byte) {
+			stored := snapshot
+			stored.Body = ioutil.NopCloser(bytes.NewReader(body))
+			stored.ContentLength = int64(len(body))
+			data, err := encodeResponse(&stored)
+			if err != nil {
+				return
+			}
+			entry.Response = data
+			t.save(key, entry)
+		},
+	}
+}
+
+// load return the entry of key if it matches the Vary of req,
+// only one variant is kept for a key, a mismatched one is replaced
+// once the new response is stored.
+func (t *cacheTransport) load(key string, req *http.Request) (*cacheEntry, bool) {
+	data, ok := t.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entry); err != nil {
+		t.cache.Delete(key)
+		return nil, false
+	}
+	for k, v := range entry.Vary {
+		if req.Header.Get(k) != v {
+			return nil, false
+		}
+	}
+	return &entry, true
+}
+
+func (t *cacheTransport) save(key string, entry *cacheEntry) {
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(entry); err == nil {
+		t.cache.Set(key, buf.Bytes())
+	}
+}
+
+// response return the cached response of req.
+func (e *cacheEntry) response(req *http.Request) (*http.Response, error) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(e.Response)), req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Header.Set("X-From-Cache", "1")
+	return resp, nil
+}
+
+// staleness return how long the entry has been stale, a negative value
+// means it's fresh and can be served without revalidation, see RFC 7234 4.2.
+// The entry must be revalidated (staleness is the max) if no-cache is present.
+// A positive ttl (or the TTL of the entry) overrides the Cache-Control
+// of the response. The Cache-Control of the response is also returned.
+func (e *cacheEntry) staleness(reqHeader http.Header, now time.Time, ttl time.Duration) (time.Duration, cacheControl) {
+	header, err := readResponseHeader(e.Response)
+	if err != nil {
+		return math.MaxInt64, nil
+	}
+	respCC, reqCC := parseCacheControl(header), parseCacheControl(reqHeader)
+	if reqCC.has("no-cache") || reqHeader.Get("Pragma") == "no-cache" {
+		return math.MaxInt64, respCC
+	}
+	if ttl <= 0 {
+		ttl = e.TTL
+	}
+	if ttl > 0 {
+		return e.age(header, now) - ttl, respCC
+	}
+	if respCC.has("no-cache") {
+		return math.MaxInt64, respCC
+	}
+
+	lifetime := freshnessLifetime(header, respCC)
+	if v, ok := reqCC.seconds("max-age"); ok && v < lifetime {
+		lifetime = v
+	}
+	age := e.age(header, now)
+	if v, ok := reqCC.seconds("min-fresh"); ok {
+		age += v
+	}
+	if v, ok := reqCC["max-stale"]; ok && !respCC.has("must-revalidate") {
+		if v == "" {
+			return -1, respCC
+		}
+		if d, ok := reqCC.seconds("max-stale"); ok {
+			lifetime += d
+		}
+	}
+	return age - lifetime, respCC
+}
+
+// age return the current age of the entry, RFC 7234 4.2.3.
+func (e *cacheEntry) age(header http.Header, now time.Time) time.Duration {
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		date = e.ResponseTime
+	}
+	apparent := e.ResponseTime.Sub(date)
+	if apparent < 0 {
+		apparent = 0
+	}
+	ageValue, _ := strconv.Atoi(header.Get("Age"))
+	corrected := time.Duration(ageValue)*time.Second + e.ResponseTime.Sub(e.RequestTime)
+	if apparent > corrected {
+		corrected = apparent
+	}
+	return corrected + now.Sub(e.ResponseTime)
+}
+
+// freshnessLifetime return the freshness lifetime of the response, RFC 7234 4.2.1.
+func freshnessLifetime(header http.Header, cc cacheControl) time.Duration {
+	if v, ok := cc.seconds("max-age"); ok {
+		return v
+	}
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		return 0
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(date)
+	}
+	// heuristic freshness: 10% of the time since last modified.
+	if lm, err := http.ParseTime(header.Get("Last-Modified")); err == nil && lm.Before(date) {
+		return date.Sub(lm) / 10
+	}
+	return 0
+}
+
+func hasFreshnessInfo(header http.Header, cc cacheControl) bool {
+	_, ok := cc["max-age"]
+	return ok || header.Get("Expires") != "" ||
+		header.Get("ETag") != "" || header.Get("Last-Modified") != ""
+}
+
+func hasConditional(header http.Header) bool {
+	return header.Get("If-None-Match") != "" || header.Get("If-Modified-Since") != "" ||
+		header.Get("If-Match") != "" || header.Get("If-Unmodified-Since") != "" ||
+		header.Get("Range") != ""
+}
+
+func isUnsafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+	return true
+}
+
+// cacheKey return the key of the request URL, the credential is hashed
+// into the key so the responses of different users are never mixed.
+func cacheKey(req *http.Request) string {
+	key := req.URL.String()
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		sum := sha256.Sum256([]byte(auth))
+		key += "#" + hex.EncodeToString(sum[:8])
+	}
+	return key
+}
+
+func varyValues(reqHeader, respHeader http.Header) map[string]string {
+	vary := make(map[string]string)
+	for _, v := range respHeader.Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			field = http.CanonicalHeaderKey(strings.TrimSpace(field))
+			if field != "" {
+				vary[field] = reqHeader.Get(field)
+			}
+		}
+	}
+	return vary
+}
+
+func encodeResponse(resp *http.Response) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := resp.Write(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func readResponseHeader(data []byte) (http.Header, error) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp.Header, nil
+}
+
+// cacheControl is the parsed directives of the Cache-Control header.
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	cc := make(cacheControl)
+	for _, v := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(v, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, value := part, ""
+			if i := strings.Index(part, "="); i >= 0 {
+				name, value = part[:i], strings.Trim(part[i+1:], `"`)
+			}
+			cc[strings.ToLower(name)] = value
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(name string) bool {
+	_, ok := cc[name]
+	return ok
+}
+
+func (cc cacheControl) seconds(name string) (time.Duration, bool) {
+	v, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+// cachingBody calls onEOF with the whole body once it's read to EOF.
+type cachingBody struct {
+	io.ReadCloser
+	buf   bytes.Buffer
+	onEOF func(body []byte)
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF && b.onEOF != nil {
+		b.onEOF(b.buf.Bytes())
+		b.onEOF = nil
+	}
+	return n, err
+}
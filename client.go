@@ -6,6 +6,8 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -13,6 +15,120 @@ import (
 type Config struct {
 	Timeout   time.Duration
 	Transport http.RoundTripper
+	// Jar is the cookie jar shared by the requests of the client,
+	// see WithNoCookies and WithCookieJar.
+	Jar http.CookieJar
+
+	// BaseURLs are the replicas of a service, a relative URL such as
+	// "/users" is joined to one of them picked by the Balancer.
+	BaseURLs []string
+	// Balancer picks the BaseURLs, default NewRoundRobin().
+	Balancer Balancer
+	// Canary routes a percentage of the requests of the BaseURLs
+	// to its URL, which is tagged by the Result.Canary.
+	Canary *Canary
+	// Discoverer discovers the BaseURLs instead, it's polled every
+	// DiscoveryInterval (default 30 seconds) and refreshed on its watch
+	// signals, call Client.Close to stop it.
+	Discoverer        Discoverer
+	DiscoveryInterval time.Duration
+	// SRV is a shortcut of the Discoverer by the DNS SRV record,
+	// polled every SRV.Interval.
+	SRV *SRV
+	// Fallbacks are the backup hosts of the requests, see WithFallbackHosts.
+	Fallbacks []string
+	// HealthCheck enables the active health checks of the BaseURLs,
+	// call Client.Close to stop them.
+	HealthCheck *HealthCheck
+
+	// DialTimeout, TLSHandshakeTimeout, ResponseHeaderTimeout and
+	// IdleConnTimeout tune a clone of the Transport, or of the
+	// http.DefaultTransport if it's nil, zero keeps the original value.
+	// NOTE they are ignored if the Transport is not a *http.Transport.
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	IdleConnTimeout       time.Duration
+
+	// ArrayStyle is the default encoding style
+	// of the query keys with multiple values.
+	ArrayStyle ArrayStyle
+
+	// BasicAuth is the default credential of the requests,
+	// for the clients dedicated to one API.
+	BasicAuth *BasicAuth
+	// BearerToken is the default bearer token of the requests,
+	// it takes precedence over the BasicAuth.
+	BearerToken string
+	// AuthProvider is the default challenge-response authentication
+	// of the requests, such as NTLM.
+	AuthProvider AuthProvider
+	// OAuth1 signs all requests with the OAuth 1.0a credentials.
+	OAuth1 *OAuth1
+
+	// UserAgent is the default User-Agent of the requests,
+	// default DefaultUserAgent.
+	UserAgent string
+
+	// AllowHosts and DenyHosts restrict the hosts of the requests
+	// including the redirects and the fallbacks, the patterns are globs
+	// like "*.example.com" or regexps with the prefix "regexp:",
+	// the DenyHosts take precedence, see ErrHostDenied.
+	AllowHosts []string
+	DenyHosts  []string
+
+	// MaxRequestBodyBytes fails the requests whose body exceeds it before
+	// sending, or once exceeded if the body length is unknown,
+	// see ErrBodyTooLarge. Zero means no limit.
+	MaxRequestBodyBytes int64
+
+	// RedactQuery is the query params masked in the errors,
+	// such as "api_key", the userinfo of the URLs is always stripped.
+	RedactQuery []string
+	// Redaction is the sensitive data masked in the errors and the dumps
+	// of the debug mode, its Query is merged with the RedactQuery.
+	Redaction Redaction
+
+	// RequestID injects a request ID into every request, which is taken
+	// from the context (see ContextWithRequestID) or generated,
+	// the ID is also included in the errors and the Result.
+	RequestID bool
+	// RequestIDHeader is the header of the request ID, default "X-Request-ID".
+	RequestIDHeader string
+
+	// TraceContext sets the W3C traceparent and tracestate headers
+	// into every request, see WithTraceContext.
+	TraceContext bool
+
+	// Cache enables the RFC 7234 HTTP cache of the GET requests,
+	// the fresh responses are served from it and the stale ones
+	// are revalidated transparently, see NewMemoryCache.
+	Cache Cache
+	// ETagCache enables a lightweight cache independent of the Cache,
+	// it remembers the ETag and Last-Modified of the GET responses,
+	// sends them as the conditional headers and serves the remembered
+	// body on 304, regardless of the freshness of the responses.
+	ETagCache Cache
+
+	// MaxRedirects is the max redirects to follow, default 10,
+	// a negative value fails the request on any redirect.
+	MaxRedirects int
+	// RedirectHook is invoked before following each redirect,
+	// see WithRedirectHook.
+	RedirectHook func(req *http.Request, via []*http.Request) error
+
+	// Retry is the default retry policy of the requests, see WithRetry.
+	Retry RetryPolicy
+
+	// OnSlowRequest is invoked with the request and its duration
+	// if it's not finished within the SlowThreshold, the duration is
+	// up to the response headers including the retries and the redirects,
+	// or up to the failure.
+	SlowThreshold time.Duration
+	OnSlowRequest func(req *http.Request, d time.Duration)
+	// ConnHook receives the connection lifecycle events
+	// of the requests, see WithConnHook.
+	ConnHook ConnHook
 }
 
 // Client wraps a HTTP Client that support functional options
@@ -22,6 +138,15 @@ type Client struct {
 	hc     *http.Client
 	config Config
 	opt    []Option
+
+	// transports caches the transports derived from hc.Transport.
+	transports sync.Map
+	health     *healthChecker
+	discovery  *discovery
+	hosts      *hostPolicy
+
+	// reloaded is the Client of the latest Config, see Reload.
+	reloaded atomic.Pointer[Client]
 }
 
 var defaultClient = Client{
@@ -33,16 +158,98 @@ var defaultClient = Client{
 	opt: make([]Option, 0),
 }
 
-// NewClient return a Client instance.
+// DefaultUserAgent is the User-Agent of the requests
+// unless the Config.UserAgent or WithUserAgent is set.
+const DefaultUserAgent = "xreq (+https://github.com/ehyyoj/xreq)"
+
+// NewClient return a Client instance, opt are the default options
+// of the requests, which are applied before the options of each
+// request so the latter take precedence, wrap the ones that must win
+// with WithOverride instead.
 func NewClient(conf Config, opt ...Option) *Client {
-	return &Client{
+	conf.Transport = tuneTransport(conf)
+	if conf.Balancer == nil {
+		conf.Balancer = NewRoundRobin()
+	}
+	c := &Client{
 		hc: &http.Client{
-			Transport: conf.Transport,
+			Transport: wrapTransport(conf, conf.Transport),
 			Timeout:   conf.Timeout,
+			Jar:       conf.Jar,
 		},
 		config: conf,
 		opt:    opt,
+		hosts:  newHostPolicy(conf.AllowHosts, conf.DenyHosts),
+	}
+	if conf.Discoverer != nil {
+		c.discovery = newDiscovery(conf.Discoverer, conf.DiscoveryInterval)
+	} else if conf.SRV != nil {
+		c.discovery = newDiscovery(conf.SRV, conf.SRV.Interval)
+	}
+	if conf.HealthCheck != nil && (len(conf.BaseURLs) > 0 || c.discovery != nil) {
+		c.health = newHealthChecker(*conf.HealthCheck, c.baseURLs, conf.Transport)
+	}
+	return c
+}
+
+// Close stops the background work of the client,
+// such as the health checks and the discovery.
+func (c *Client) Close() {
+	if r := c.reloaded.Load(); r != nil {
+		r.stop()
+	}
+	c.stop()
+}
+
+// stop stops the background work of the Config of c.
+func (c *Client) stop() {
+	if c.health != nil {
+		c.health.close()
+	}
+	if c.discovery != nil {
+		c.discovery.close()
+	}
+}
+
+// Reload swap the Config of the client atomically, the subsequent
+// requests use conf while the in-flight ones keep the previous Config
+// until they finish. The default options of the client are kept,
+// the background work of the previous Config is stopped and the idle
+// connections of its transport are closed unless conf shares it.
+//
+// Example:
+//
+// for conf := range watcher {
+//     cli.Reload(conf)
+// }
+func (c *Client) Reload(conf Config) {
+	next := NewClient(conf, c.opt...)
+	prev := c.reloaded.Swap(next)
+	if prev == nil {
+		prev = c
 	}
+	prev.stop()
+	prev.closeIdle(next)
+}
+
+// closeIdle closes the idle connections of the transports of c
+// which are not shared with next, so the reloads don't leak them.
+func (c *Client) closeIdle(next *Client) {
+	if c.config.Transport != next.config.Transport {
+		closeIdleConnections(c.config.Transport)
+	}
+	c.transports.Range(func(_, t interface{}) bool {
+		closeIdleConnections(t.(http.RoundTripper))
+		return true
+	})
+}
+
+// current return the Client of the latest Config.
+func (c *Client) current() *Client {
+	if r := c.reloaded.Load(); r != nil {
+		return r
+	}
+	return c
 }
 
 // Get issues a GET with options to the specified URL
@@ -156,38 +363,270 @@ func (c *Client) DoBytes(url string, opt ...Option) (data []byte, code int, err
 	if err != nil {
 		return
 	}
+	data, err = readBody(opts, resp)
+	return data, resp.StatusCode, err
+}
+
+// readBody read and close the body of resp,
+// then check the trailers and the status of resp with opts.
+func readBody(opts *Options, resp *http.Response) ([]byte, error) {
 	defer resp.Body.Close()
 
-	data, err = ioutil.ReadAll(resp.Body)
+	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return data, resp.StatusCode, fmt.Errorf("read body error: %w", err)
+		err = fmt.Errorf("read body error: %w", err)
+		return data, requestError(resp.Request, opts.redactQuery, err)
+	}
+	if err = checkTrailers(resp, opts.trailers); err != nil {
+		return data, requestError(resp.Request, opts.redactQuery, err)
 	}
+	return data, checkStatus(opts, resp, data)
+}
 
-	// treat non-2xx as error will be better?
-	if opts.checkStatus && resp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("http status code: %d", resp.StatusCode)
+// BuildRequest runs the options and return the built request without
+// sending it, it's useful for signing, inspection and the unit tests
+// of the options.
+func BuildRequest(url string, opt ...Option) (*http.Request, error) {
+	return defaultClient.BuildRequest(url, opt...)
+}
+
+// BuildRequest runs the client options and opt,
+// then return the built request without sending it.
+func (c *Client) BuildRequest(url string, opt ...Option) (*http.Request, error) {
+	opts := &Options{}
+	_, err := c.build(opts, url, opt...)
+	if opts.picked != nil {
+		opts.picked()
 	}
-	return data, resp.StatusCode, err
+	if err != nil {
+		return nil, err
+	}
+	return opts.Request, nil
 }
 
-func (c *Client) do(opts *Options, url string, opt ...Option) (resp *http.Response, err error) {
+// build builds opts.Request with the options and return the request ID.
+func (c *Client) build(opts *Options, url string, opt ...Option) (reqID string, err error) {
+	c = c.current()
+	url = c.resolveBaseURL(opts, url)
 	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("new request error: %w", err)
+		return "", fmt.Errorf("new request error: %w", err)
 	}
 
 	opts.Request = req
 	opts.Values = req.URL.Query()
 	opts.checkStatus = false
+	opts.expectStatus = nil
+	opts.errorDecoder = nil
+	opts.arrayStyle = c.config.ArrayStyle
+	opts.authProvider = c.config.AuthProvider
+	opts.traceContext = c.config.TraceContext
+	opts.maxRedirects = c.config.MaxRedirects
+	opts.retry = c.config.Retry
+	opts.redaction = c.redaction()
+	opts.redactQuery = opts.redaction.Query
+	opts.fallbacks = c.config.Fallbacks
+	opts.connHook = c.config.ConnHook
+	if opts.maxRedirects == 0 {
+		opts.maxRedirects = defaultMaxRedirects
+	}
+	if c.hosts != nil {
+		opts.redirectHooks = append(opts.redirectHooks, func(req *http.Request, via []*http.Request) error {
+			return c.hosts.check(req.URL.Hostname())
+		})
+	}
+	if c.config.RedirectHook != nil {
+		opts.redirectHooks = append(opts.redirectHooks, c.config.RedirectHook)
+	}
+	if c.config.OAuth1 != nil {
+		opts.signers = append(opts.signers, c.config.OAuth1.Sign)
+	}
 
-	allOpt := append(c.opt, opt...)
+	// the client options, the request options, then the overrides.
+	allOpt := make([]Option, 0, len(c.opt)+len(opt))
+	allOpt = append(append(allOpt, c.opt...), opt...)
 	for _, o := range allOpt {
-		o(opts)
+		o.apply(opts)
+		if opts.Err != nil {
+			return "", fmt.Errorf("option exec error: %w", opts.Err)
+		}
+	}
+	for i := 0; i < len(opts.overrides); i++ {
+		opts.overrides[i].apply(opts)
 		if opts.Err != nil {
-			return nil, fmt.Errorf("option exec error: %w", opts.Err)
+			return "", fmt.Errorf("option exec error: %w", opts.Err)
+		}
+	}
+	if err = opts.checkConflicts(); err != nil {
+		return "", fmt.Errorf("option exec error: %w", err)
+	}
+	mergeContextHeaders(opts.Request)
+	c.setDefaultAuth(opts.Request)
+	c.setUserAgent(opts.Request)
+	if c.config.RequestID {
+		reqID = c.setRequestID(opts.Request)
+	}
+	if opts.traceContext {
+		// the traceparent is "00-<trace id>-<span id>-<flags>".
+		parent := setTraceContext(opts.Request)
+		opts.traceID, opts.spanID = parent[3:35], parent[36:52]
+	}
+	delHeaders(opts.Request, opts.delHeaders)
+	if len(opts.pathParams) > 0 {
+		if err = expandPath(opts.Request.URL, opts.pathParams); err != nil {
+			return "", err
+		}
+	}
+
+	var order []string
+	if opts.keepOrder {
+		order = append(parseQueryKeys(req.URL.RawQuery), opts.queryKeys...)
+	}
+	opts.Request.URL.RawQuery = encodeQuery(opts.Values, opts.arrayStyle, order)
+	if opts.replayBuffer > 0 {
+		replayableBody(opts.Request, opts.replayBuffer)
+	}
+	if max := c.config.MaxRequestBodyBytes; max > 0 {
+		if err = limitBody(opts.Request, max); err != nil {
+			return "", err
+		}
+	}
+	if opts.chunked && opts.Request.Body != nil && opts.Request.Body != http.NoBody {
+		opts.Request.ContentLength = -1
+		opts.Request.TransferEncoding = []string{"chunked"}
+	}
+	if opts.gzipBody {
+		gzipBody(opts.Request, opts.gzipLevel)
+	}
+
+	if opts.route != "" {
+		opts.Request = setRoute(opts.Request, opts.route)
+	}
+	if opts.connHook != nil {
+		opts.Request = traceConn(opts.Request, opts.connHook)
+	}
+	opts.decode = shouldDecode(opts.Request)
+	if opts.decode {
+		opts.Request.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	if err = opts.sign(opts.Request); err != nil {
+		return "", err
+	}
+	return reqID, nil
+}
+
+func (c *Client) do(opts *Options, url string, opt ...Option) (resp *http.Response, err error) {
+	c = c.current()
+	reqID, err := c.build(opts, url, opt...)
+	if opts.picked != nil {
+		defer func() {
+			if err != nil {
+				opts.picked()
+				return
+			}
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: opts.picked}
+		}()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.deadlineMargin > 0 {
+		var cancel context.CancelFunc
+		opts.Request, cancel = withDeadlineMargin(opts.Request, opts.deadlineMargin)
+		defer func() {
+			if err != nil {
+				cancel()
+				return
+			}
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+		}()
+	}
+	if opts.cacheDirective != (cacheDirective{}) {
+		ctx := context.WithValue(opts.Request.Context(), cacheDirectiveKey{}, opts.cacheDirective)
+		opts.Request = opts.Request.WithContext(ctx)
+	}
+
+	if err = c.hosts.check(opts.Request.URL.Hostname()); err != nil {
+		return nil, requestError(opts.Request, opts.redactQuery, err)
+	}
+	hc, err := c.httpClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.redirectTrace != nil {
+		opts.redirectTrace.begin()
+	}
+	if opts.result != nil {
+		*opts.result = Result{}
+	}
+	start := time.Now()
+	resp, err = c.sendFallback(hc, opts)
+	if err == nil && opts.reauth != nil {
+		resp, err = c.reauth(hc, opts, resp)
+	}
+	if d := time.Since(start); c.config.OnSlowRequest != nil &&
+		c.config.SlowThreshold > 0 && d > c.config.SlowThreshold {
+		c.config.OnSlowRequest(opts.Request, d)
+	}
+	if err != nil {
+		err = requestError(opts.Request, opts.redactQuery, err)
+		if opts.traceID != "" {
+			err = fmt.Errorf("trace %s: %w", opts.traceID, err)
 		}
+		if reqID != "" {
+			err = fmt.Errorf("request %s: %w", reqID, err)
+		}
+		return nil, err
+	}
+	if opts.redirectTrace != nil {
+		opts.redirectTrace.record(resp.Request.URL.String(), resp.StatusCode)
 	}
-	opts.Request.URL.RawQuery = opts.Values.Encode()
 
-	return c.hc.Do(opts.Request)
+	if opts.result != nil {
+		opts.result.finish(resp, start)
+		opts.result.RequestID = reqID
+		opts.result.TraceID = opts.traceID
+		opts.result.SpanID = opts.spanID
+		opts.result.Route = opts.route
+		opts.result.Host = opts.Request.URL.Host
+		opts.result.Canary = opts.canary
+	}
+	if opts.decode {
+		enc := decodeBody(resp)
+		if opts.result != nil {
+			opts.result.ContentEncoding = enc
+		}
+	}
+	return resp, nil
+}
+
+// redaction return the Config.Redaction merged with the Config.RedactQuery.
+func (c *Client) redaction() Redaction {
+	r := c.config.Redaction
+	if len(c.config.RedactQuery) > 0 {
+		r.Query = append(append([]string(nil), c.config.RedactQuery...), r.Query...)
+	}
+	return r
+}
+
+// setUserAgent set the default User-Agent unless req has one,
+// an empty User-Agent set by the options is kept to send none.
+func (c *Client) setUserAgent(req *http.Request) {
+	if _, ok := req.Header["User-Agent"]; ok {
+		return
+	}
+	ua := c.config.UserAgent
+	if ua == "" {
+		ua = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", ua)
+}
+
+func (c *Client) send(hc *http.Client, req *http.Request, p AuthProvider) (*http.Response, error) {
+	if p != nil {
+		return authenticate(hc, req, p)
+	}
+	return hc.Do(req)
 }
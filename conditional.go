@@ -0,0 +1,35 @@
+package xreq
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrNotModified is returned by the methods with bytes return instead of
+// the status error when WithCheckStatus is enabled and the response is
+// 304 Not Modified, it means the cached copy of the caller is still valid.
+var ErrNotModified = errors.New("not modified")
+
+// WithIfNoneMatch set the If-None-Match header with the etag,
+// it's quoted if not yet.
+func WithIfNoneMatch(etag string) Option {
+	return func(o *Options) {
+		o.Request.Header.Set("If-None-Match", quoteETag(etag))
+	}
+}
+
+// WithIfModifiedSince set the If-Modified-Since header with t.
+func WithIfModifiedSince(t time.Time) Option {
+	return func(o *Options) {
+		o.Request.Header.Set("If-Modified-Since", t.UTC().Format(http.TimeFormat))
+	}
+}
+
+func quoteETag(etag string) string {
+	if etag == "*" || strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
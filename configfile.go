@@ -0,0 +1,166 @@
+package xreq
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is the serializable part of the Config,
+// which is loaded from a YAML or JSON file, see LoadConfigFile.
+//
+// Example:
+//
+//	timeout: 5s
+//	base_urls: ["https://a.example.com", "https://b.example.com"]
+//	headers:
+//	  X-Tenant: acme
+//	retry:
+//	  max_attempts: 3
+//	  backoff: 100ms
+//	tls:
+//	  ca_file: /etc/ssl/ca.pem
+type ConfigFile struct {
+	Timeout               Duration `json:"timeout"`
+	DialTimeout           Duration `json:"dial_timeout"`
+	TLSHandshakeTimeout   Duration `json:"tls_handshake_timeout"`
+	ResponseHeaderTimeout Duration `json:"response_header_timeout"`
+	IdleConnTimeout       Duration `json:"idle_conn_timeout"`
+
+	// Proxy is the proxy URL, default from the HTTP_PROXY.
+	Proxy string   `json:"proxy"`
+	TLS   TLSFiles `json:"tls"`
+
+	Retry struct {
+		MaxAttempts    int      `json:"max_attempts"`
+		Backoff        Duration `json:"backoff"`
+		AttemptTimeout Duration `json:"attempt_timeout"`
+		Timeout        Duration `json:"timeout"`
+	} `json:"retry"`
+
+	BaseURLs  []string `json:"base_urls"`
+	Fallbacks []string `json:"fallbacks"`
+	// Headers are the default headers of the requests.
+	Headers     map[string]string `json:"headers"`
+	UserAgent   string            `json:"user_agent"`
+	BearerToken string            `json:"bearer_token"`
+
+	AllowHosts          []string `json:"allow_hosts"`
+	DenyHosts           []string `json:"deny_hosts"`
+	MaxRequestBodyBytes int64    `json:"max_request_body_bytes"`
+	MaxRedirects        int      `json:"max_redirects"`
+	RedactQuery         []string `json:"redact_query"`
+	RequestID           bool     `json:"request_id"`
+}
+
+// Duration is a time.Duration unmarshaled from
+// a string such as "1.5s" or a number of nanoseconds.
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		*d = Duration(v)
+	case string:
+		dur, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = Duration(dur)
+	default:
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	return nil
+}
+
+// LoadConfigFile load the ConfigFile of path,
+// the files with the extension ".yaml" or ".yml" are YAML,
+// the others are JSON.
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file error: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// convert to JSON so both share the tags and the Duration.
+		var v interface{}
+		if err = yaml.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("yaml unmarshal error: %w", err)
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("json marshal error: %w", err)
+		}
+	}
+
+	f := &ConfigFile{}
+	if err = json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("json unmarshal error: %w", err)
+	}
+	return f, nil
+}
+
+// NewClientFromFile load the ConfigFile of path and return its Client,
+// see LoadConfigFile and ConfigFile.NewClient.
+func NewClientFromFile(path string, opt ...Option) (*Client, error) {
+	f, err := LoadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return f.NewClient(opt...)
+}
+
+// Config return the Config of f,
+// the Transport is built with the Proxy and the TLS.
+func (f *ConfigFile) Config() (Config, error) {
+	t, err := newTransport(f.Proxy, f.TLS)
+	if err != nil {
+		return Config{}, err
+	}
+	return Config{
+		Timeout:               time.Duration(f.Timeout),
+		Transport:             t,
+		DialTimeout:           time.Duration(f.DialTimeout),
+		TLSHandshakeTimeout:   time.Duration(f.TLSHandshakeTimeout),
+		ResponseHeaderTimeout: time.Duration(f.ResponseHeaderTimeout),
+		IdleConnTimeout:       time.Duration(f.IdleConnTimeout),
+		Retry: RetryPolicy{
+			MaxAttempts:    f.Retry.MaxAttempts,
+			Backoff:        time.Duration(f.Retry.Backoff),
+			AttemptTimeout: time.Duration(f.Retry.AttemptTimeout),
+			Timeout:        time.Duration(f.Retry.Timeout),
+		},
+		BaseURLs:            f.BaseURLs,
+		Fallbacks:           f.Fallbacks,
+		UserAgent:           f.UserAgent,
+		BearerToken:         f.BearerToken,
+		AllowHosts:          f.AllowHosts,
+		DenyHosts:           f.DenyHosts,
+		MaxRequestBodyBytes: f.MaxRequestBodyBytes,
+		MaxRedirects:        f.MaxRedirects,
+		RedactQuery:         f.RedactQuery,
+		RequestID:           f.RequestID,
+	}, nil
+}
+
+// NewClient return the Client of f, the Headers are set before opt.
+func (f *ConfigFile) NewClient(opt ...Option) (*Client, error) {
+	conf, err := f.Config()
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Headers) > 0 {
+		opt = append([]Option{WithHeaders(f.Headers)}, opt...)
+	}
+	return NewClient(conf, opt...), nil
+}
@@ -0,0 +1,25 @@
+package xreq
+
+import (
+	"fmt"
+	"strings"
+)
+
+// setBodyOption records the option which sets the request body.
+func (o *Options) setBodyOption(name string) {
+	o.bodyOptions = append(o.bodyOptions, name)
+}
+
+// checkConflicts return the error of the options whose effects depend on
+// their order, such as two options setting the request body.
+func (o *Options) checkConflicts() error {
+	if len(o.discarded) > 0 {
+		return fmt.Errorf("conflicting options: WithRequest discards %s",
+			strings.Join(o.discarded, ", "))
+	}
+	if len(o.bodyOptions) > 1 {
+		return fmt.Errorf("conflicting options: %s all set the request body",
+			strings.Join(o.bodyOptions, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package xreq
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// ConnEventType is the type of a connection lifecycle event.
+type ConnEventType int
+
+const (
+	// EventDNSStart is sent before the DNS lookup of the Host.
+	EventDNSStart ConnEventType = iota
+	// EventDNSDone is sent after the DNS lookup of the Host.
+	EventDNSDone
+	// EventConnectStart is sent before dialing the Addr.
+	EventConnectStart
+	// EventConnectDone is sent after dialing the Addr.
+	EventConnectDone
+	// EventTLSHandshakeStart is sent before the TLS handshake.
+	EventTLSHandshakeStart
+	// EventTLSHandshakeDone is sent after the TLS handshake.
+	EventTLSHandshakeDone
+	// EventGotConn is sent once a connection to the Addr is obtained,
+	// which is new or Reused from the pool.
+	EventGotConn
+	// EventWroteRequest is sent after the request is written.
+	EventWroteRequest
+)
+
+// ConnEvent is a connection lifecycle event of a request.
+type ConnEvent struct {
+	Type ConnEventType
+	// Host is the host looked up by the DNS events.
+	Host string
+	// Addr is the remote address of the connect events and EventGotConn.
+	Addr string
+	// Reused reports whether EventGotConn reused a pooled connection.
+	Reused bool
+	// Duration is the time since the start event of the done events.
+	Duration time.Duration
+	// Err is the failure of the done events and EventWroteRequest.
+	Err error
+}
+
+// ConnHook receives the connection lifecycle events of the requests,
+// it may be invoked concurrently, e.g. dialing the addresses of a host
+// in parallel. See Config.ConnHook and WithConnHook.
+type ConnHook interface {
+	ConnEvent(ev ConnEvent)
+}
+
+// ConnHookFunc is an adapter to use a function as a ConnHook.
+type ConnHookFunc func(ev ConnEvent)
+
+// ConnEvent implements ConnHook.
+func (f ConnHookFunc) ConnEvent(ev ConnEvent) {
+	f(ev)
+}
+
+// WithConnHook set the hook of the connection lifecycle events
+// of the request, it overrides the Config.ConnHook.
+func WithConnHook(h ConnHook) Option {
+	return func(o *Options) {
+		o.connHook = h
+	}
+}
+
+// traceConn instruments req to send the connection events to h.
+func traceConn(req *http.Request, h ConnHook) *http.Request {
+	var (
+		mu       sync.Mutex
+		dnsStart time.Time
+		tlsStart time.Time
+		dials    = make(map[string]time.Time)
+	)
+	since := func(t *time.Time) time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+		return time.Since(*t)
+	}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			mu.Lock()
+			dnsStart = time.Now()
+			mu.Unlock()
+			h.ConnEvent(ConnEvent{Type: EventDNSStart, Host: info.Host})
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			h.ConnEvent(ConnEvent{Type: EventDNSDone, Duration: since(&dnsStart), Err: info.Err})
+		},
+		ConnectStart: func(network, addr string) {
+			mu.Lock()
+			dials[addr] = time.Now()
+			mu.Unlock()
+			h.ConnEvent(ConnEvent{Type: EventConnectStart, Addr: addr})
+		},
+		ConnectDone: func(network, addr string, err error) {
+			mu.Lock()
+			start := dials[addr]
+			delete(dials, addr)
+			mu.Unlock()
+			h.ConnEvent(ConnEvent{Type: EventConnectDone, Addr: addr, Duration: time.Since(start), Err: err})
+		},
+		TLSHandshakeStart: func() {
+			mu.Lock()
+			tlsStart = time.Now()
+			mu.Unlock()
+			h.ConnEvent(ConnEvent{Type: EventTLSHandshakeStart})
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			h.ConnEvent(ConnEvent{Type: EventTLSHandshakeDone, Duration: since(&tlsStart), Err: err})
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			h.ConnEvent(ConnEvent{Type: EventGotConn, Addr: connAddr(info.Conn), Reused: info.Reused})
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			h.ConnEvent(ConnEvent{Type: EventWroteRequest, Err: info.Err})
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+func connAddr(conn net.Conn) string {
+	if conn == nil || conn.RemoteAddr() == nil {
+		return ""
+	}
+	return conn.RemoteAddr().String()
+}
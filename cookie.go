@@ -0,0 +1,44 @@
+package xreq
+
+import (
+	"net/http"
+	"sort"
+)
+
+// WithCookies set the cookies of the map into the request,
+// which are sent in the order of the names.
+func WithCookies(cookies map[string]string) Option {
+	return func(o *Options) {
+		names := make([]string, 0, len(cookies))
+		for name := range cookies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			o.Request.AddCookie(&http.Cookie{Name: name, Value: cookies[name]})
+		}
+	}
+}
+
+// WithNoCookies send the request without the Config.Jar of the client,
+// the cookies of the jar are neither sent nor updated by the response.
+func WithNoCookies() Option {
+	return func(o *Options) {
+		o.noCookies = true
+		o.jar = nil
+	}
+}
+
+// WithCookieJar send the request with jar instead of the Config.Jar
+// of the client, e.g. to act as a different identity.
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(o *Options) {
+		o.noCookies = jar == nil
+		o.jar = jar
+	}
+}
+
+// captureCookies append the Set-Cookie of resp to r.
+func (r *Result) captureCookies(resp *http.Response) {
+	r.CapturedCookies = append(r.CapturedCookies, resp.Cookies()...)
+}
@@ -0,0 +1,28 @@
+package xreq
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// WithDeadlineMargin derive the deadline of the request from the deadline
+// of its context minus the margin, so a handler making downstream calls
+// leaves time to render its own response. It does nothing if the context
+// has no deadline, and the request fails at once if the margin exceeds
+// the remaining time.
+func WithDeadlineMargin(margin time.Duration) Option {
+	return func(o *Options) {
+		o.deadlineMargin = margin
+	}
+}
+
+// withDeadlineMargin return req with the deadline shortened by margin.
+func withDeadlineMargin(req *http.Request, margin time.Duration) (*http.Request, context.CancelFunc) {
+	deadline, ok := req.Context().Deadline()
+	if !ok {
+		return req, func() {}
+	}
+	ctx, cancel := context.WithDeadline(req.Context(), deadline.Add(-margin))
+	return req.WithContext(ctx), cancel
+}
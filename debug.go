@@ -0,0 +1,131 @@
+package xreq
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	urlpkg "net/url"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// debugMaxBody is the max bytes of the bodies dumped in the debug mode.
+const debugMaxBody = 64 << 10
+
+var (
+	debug atomic.Bool
+
+	debugMu     sync.Mutex
+	debugOutput io.Writer = os.Stderr
+)
+
+func init() {
+	enable, _ := strconv.ParseBool(os.Getenv("XREQ_DEBUG"))
+	debug.Store(enable)
+}
+
+// SetDebug turn on or off the debug mode of all clients, which dumps
+// the requests and the responses to the stderr, see SetDebugOutput.
+// The sensitive headers are masked, the bodies are dumped only if
+// their length is known and up to 64KB. It's also turned on by
+// the environment variable XREQ_DEBUG=1.
+func SetDebug(enable bool) {
+	debug.Store(enable)
+}
+
+// SetDebugOutput set the output of the debug mode, default os.Stderr.
+func SetDebugOutput(w io.Writer) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	debugOutput = w
+}
+
+// debugTransport dumps the requests and the responses of base,
+// the sensitive data is masked by redaction.
+type debugTransport struct {
+	base      http.RoundTripper
+	redaction Redaction
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	writeDebug(t.dumpRequest(req))
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		writeDebug([]byte(fmt.Sprintf("%s %s error: %v\n\n", req.Method, redactURL(req.URL, t.redaction.Query), err)))
+		return nil, err
+	}
+	writeDebug(t.dumpResponse(resp))
+	return resp, nil
+}
+
+// dumpRequest dump req with the body read by GetBody,
+// so req is not changed.
+func (t *debugTransport) dumpRequest(req *http.Request) []byte {
+	r := req.Clone(req.Context())
+	r.Header = redactHeader(req.Header, t.redaction.Headers, true)
+	if q := t.redaction.Query; len(q) > 0 {
+		r.URL, _ = urlpkg.Parse(redactURL(req.URL, q))
+	}
+	data, err := httputil.DumpRequestOut(r, false)
+	if err != nil {
+		return []byte(fmt.Sprintf("dump request error: %v\n\n", err))
+	}
+	return append(data, t.debugBody(req.ContentLength, req.GetBody)...)
+}
+
+// dumpResponse dump resp, the dumped body is restored into resp.
+func (t *debugTransport) dumpResponse(resp *http.Response) []byte {
+	r := *resp
+	r.Header = redactHeader(resp.Header, t.redaction.Headers, true)
+	data, err := httputil.DumpResponse(&r, false)
+	if err != nil {
+		return []byte(fmt.Sprintf("dump response error: %v\n\n", err))
+	}
+
+	var body []byte
+	getBody := func() (io.ReadCloser, error) {
+		if body, err = ioutil.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	return append(data, t.debugBody(resp.ContentLength, getBody)...)
+}
+
+// debugBody return the body of length read by getBody if it's dumped,
+// the JSON fields of the redaction are masked.
+func (t *debugTransport) debugBody(length int64, getBody func() (io.ReadCloser, error)) []byte {
+	switch {
+	case length == 0:
+		return []byte("\n")
+	case length < 0 || length > debugMaxBody || getBody == nil:
+		return []byte(fmt.Sprintf("[body of %d bytes not dumped]\n\n", length))
+	}
+	body, err := getBody()
+	if err != nil {
+		return []byte(fmt.Sprintf("[read body error: %v]\n\n", err))
+	}
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return []byte(fmt.Sprintf("[read body error: %v]\n\n", err))
+	}
+	return append(redactJSON(data, t.redaction.JSONFields), "\n\n"...)
+}
+
+func writeDebug(data []byte) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	debugOutput.Write(data)
+}
@@ -0,0 +1,151 @@
+package xreq
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Discoverer is a source of the endpoints such as Consul, etcd
+// or Kubernetes, which feeds the base URLs of the Balancer.
+type Discoverer interface {
+	// Endpoints return the current base URLs.
+	Endpoints(ctx context.Context) ([]string, error)
+	// Watch return a channel signals that the endpoints changed,
+	// it can be nil if the source only supports polling.
+	Watch() <-chan struct{}
+}
+
+// SRV discovers the endpoints by resolving a DNS SRV record periodically,
+// the targets of the lowest priority are used as the base URLs.
+type SRV struct {
+	// Name is the SRV record, e.g. "_api._tcp.service.consul".
+	Name string
+	// Scheme is the scheme of the base URLs, default "http".
+	Scheme string
+	// Interval is the interval between the lookups, default 30 seconds.
+	Interval time.Duration
+	// Resolver is the DNS resolver, default net.DefaultResolver.
+	Resolver *net.Resolver
+}
+
+// Endpoints resolve the SRV record and return the base URLs.
+func (s *SRV) Endpoints(ctx context.Context) ([]string, error) {
+	resolver := s.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	_, addrs, err := resolver.LookupSRV(ctx, "", "", s.Name)
+	if err != nil {
+		return nil, fmt.Errorf("lookup srv error: %w", err)
+	}
+	scheme := s.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	// the records are sorted by priority and randomized by weight.
+	var urls []string
+	for _, addr := range addrs {
+		if addr.Priority != addrs[0].Priority {
+			break
+		}
+		host := strings.TrimSuffix(addr.Target, ".")
+		urls = append(urls, scheme+"://"+net.JoinHostPort(host, strconv.Itoa(int(addr.Port))))
+	}
+	return urls, nil
+}
+
+// Watch return nil as the SRV record is polled.
+func (s *SRV) Watch() <-chan struct{} {
+	return nil
+}
+
+// discovery keeps the endpoints updated by the Discoverer periodically
+// and on its watch signals, the last endpoints are kept if a lookup
+// fails or finds nothing.
+type discovery struct {
+	d        Discoverer
+	interval time.Duration
+	urls     atomic.Value
+	// ready is closed once the first lookup is done.
+	ready chan struct{}
+
+	// ctx is canceled by close to abort the lookup in flight.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// firstLookupTimeout bounds the first lookup, which the first requests wait for.
+const firstLookupTimeout = 5 * time.Second
+
+func newDiscovery(disc Discoverer, interval time.Duration) *discovery {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	d := &discovery{
+		d:        disc,
+		interval: interval,
+		ready:    make(chan struct{}),
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	d.urls.Store([]string(nil))
+	d.wg.Add(1)
+	go d.run()
+	return d
+}
+
+func (d *discovery) run() {
+	defer d.wg.Done()
+	// lookup once before the first request, without blocking NewClient.
+	timeout := firstLookupTimeout
+	if d.interval < timeout {
+		timeout = d.interval
+	}
+	d.refresh(timeout)
+	close(d.ready)
+
+	t := time.NewTicker(d.interval)
+	defer t.Stop()
+	watch := d.d.Watch()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-t.C:
+		case _, ok := <-watch:
+			if !ok {
+				watch = nil
+				continue
+			}
+		}
+		d.refresh(d.interval)
+	}
+}
+
+func (d *discovery) refresh(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(d.ctx, timeout)
+	defer cancel()
+	urls, err := d.d.Endpoints(ctx)
+	if err == nil && len(urls) > 0 {
+		d.urls.Store(urls)
+	}
+}
+
+// endpoints return the current endpoints,
+// it waits for the first lookup if it's in flight.
+func (d *discovery) endpoints() []string {
+	<-d.ready
+	return d.urls.Load().([]string)
+}
+
+func (d *discovery) close() {
+	d.cancel()
+	d.wg.Wait()
+}
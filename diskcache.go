@@ -0,0 +1,168 @@
+package xreq
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DiskCache is a persistent Cache storing the entries as files in a directory,
+// the total size is bounded and the least recently used entries are evicted,
+// so CLI tools and batch jobs survive restarts without refetching everything.
+// It's safe for concurrent use in a process.
+type DiskCache struct {
+	dir      string
+	maxBytes int64
+
+	mu    sync.Mutex
+	size  int64
+	lru   *list.List // of *diskItem, the front is the most recently used
+	items map[string]*list.Element
+}
+
+type diskItem struct {
+	name string
+	size int64
+}
+
+// NewDiskCache return a DiskCache in dir bounded to maxBytes,
+// the existing entries in dir are loaded in the order of their
+// modification time, maxBytes must be positive.
+func NewDiskCache(dir string, maxBytes int64) (*DiskCache, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("invalid cache size: %d", maxBytes)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("create cache dir error: %w", err)
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read cache dir error: %w", err)
+	}
+
+	c := &DiskCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		items:    make(map[string]*list.Element),
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().After(infos[j].ModTime())
+	})
+	for _, info := range infos {
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		c.items[info.Name()] = c.lru.PushBack(&diskItem{name: info.Name(), size: info.Size()})
+		c.size += info.Size()
+	}
+	c.evict()
+	return c, nil
+}
+
+// Get implements Cache.
+func (c *DiskCache) Get(key string) ([]byte, bool) {
+	name := diskName(key)
+	c.mu.Lock()
+	_, ok := c.items[name]
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	// read the file without the lock, the entries are replaced
+	// by renaming, so a concurrent Set never leaves a partial one.
+	path := filepath.Join(c.dir, name)
+	data, err := ioutil.ReadFile(path)
+	c.mu.Lock()
+	e, ok := c.items[name]
+	if ok && err != nil {
+		c.remove(e)
+	} else if ok {
+		c.lru.MoveToFront(e)
+	}
+	c.mu.Unlock()
+	if !ok || err != nil {
+		return nil, false
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return data, true
+}
+
+// Set implements Cache, the entry larger than the bound is ignored.
+func (c *DiskCache) Set(key string, entry []byte) {
+	if int64(len(entry)) > c.maxBytes {
+		return
+	}
+
+	name := diskName(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// write to a temp file then rename, so a crash never leaves a partial entry.
+	f, err := ioutil.TempFile(c.dir, ".tmp-")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(entry)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(c.dir, name))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return
+	}
+
+	if e, ok := c.items[name]; ok {
+		c.size -= e.Value.(*diskItem).size
+		c.lru.Remove(e)
+	}
+	c.items[name] = c.lru.PushFront(&diskItem{name: name, size: int64(len(entry))})
+	c.size += int64(len(entry))
+	c.evict()
+}
+
+// Delete implements Cache.
+func (c *DiskCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[diskName(key)]; ok {
+		c.remove(e)
+	}
+}
+
+// Size return the total bytes of the entries.
+func (c *DiskCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+func (c *DiskCache) evict() {
+	for c.size > c.maxBytes && c.lru.Len() > 0 {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *DiskCache) remove(e *list.Element) {
+	item := c.lru.Remove(e).(*diskItem)
+	delete(c.items, item.name)
+	c.size -= item.size
+	os.Remove(filepath.Join(c.dir, item.name))
+}
+
+func diskName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
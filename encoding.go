@@ -0,0 +1,154 @@
+package xreq
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// acceptEncoding is the Accept-Encoding sent when the caller doesn't set one.
+const acceptEncoding = "gzip, br, zstd"
+
+// decoders maps a Content-Encoding to the constructor of its decoder.
+var decoders = map[string]func(r io.Reader) (io.ReadCloser, error){
+	"gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"br": func(r io.Reader) (io.ReadCloser, error) {
+		return ioutil.NopCloser(brotli.NewReader(r)), nil
+	},
+	"zstd": func(r io.Reader) (io.ReadCloser, error) {
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	},
+}
+
+// shouldDecode reports whether the client should negotiate
+// the response encoding itself, it follows the rules of http.Transport:
+// a caller-provided Accept-Encoding or Range header disables it.
+func shouldDecode(req *http.Request) bool {
+	return req.Header.Get("Accept-Encoding") == "" &&
+		req.Header.Get("Range") == "" &&
+		req.Method != http.MethodHead
+}
+
+// decodeBody replaces resp.Body with a decoding reader
+// and return the original Content-Encoding.
+func decodeBody(resp *http.Response) string {
+	enc := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	decode, ok := decoders[enc]
+	if !ok {
+		return ""
+	}
+
+	resp.Body = &decodeReader{body: resp.Body, decode: decode}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return enc
+}
+
+// decodeReader lazily creates the decoder on the first Read,
+// so an empty body (e.g. 204 or 304) doesn't fail.
+type decodeReader struct {
+	body   io.ReadCloser
+	decode func(r io.Reader) (io.ReadCloser, error)
+	r      io.ReadCloser
+	err    error
+}
+
+func (d *decodeReader) Read(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	if d.r == nil {
+		d.r, d.err = d.decode(d.body)
+		if d.err != nil {
+			return 0, d.err
+		}
+	}
+	return d.r.Read(p)
+}
+
+func (d *decodeReader) Close() error {
+	if d.r != nil {
+		d.r.Close()
+	}
+	return d.body.Close()
+}
+
+// WithGzipBody compress the request body with gzip at the given level
+// and set the Content-Encoding header.
+// The body is streamed through the gzip writer, so the Content-Length
+// becomes unknown and the request will be sent chunked.
+func WithGzipBody(level int) Option {
+	return func(o *Options) {
+		if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+			o.Err = fmt.Errorf("invalid gzip level: %d", level)
+			return
+		}
+		o.gzipBody = true
+		o.gzipLevel = level
+	}
+}
+
+// gzipBody replaces the request body with its gzip stream,
+// it's applied after all options so the body option order doesn't matter.
+func gzipBody(req *http.Request, level int) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	req.Body = gzipReader(req.Body, level)
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return gzipReader(body, level), nil
+		}
+	}
+	req.ContentLength = -1
+	req.Header.Set("Content-Encoding", "gzip")
+}
+
+// gzipReader return a reader of the compressed body, the compression
+// runs in a goroutine which starts at the first read, see pipeStream,
+// so a request which is never sent doesn't leak it.
+func gzipReader(body io.ReadCloser, level int) io.ReadCloser {
+	return &gzipStream{
+		pipeStream: newPipeStream(func(w io.Writer) error {
+			zw, err := gzip.NewWriterLevel(w, level)
+			if err != nil {
+				return err
+			}
+			if _, err = io.Copy(zw, body); err != nil {
+				return err
+			}
+			return zw.Close()
+		}),
+		body: body,
+	}
+}
+
+// gzipStream closes the original body along with the stream.
+type gzipStream struct {
+	*pipeStream
+	body io.Closer
+}
+
+func (s *gzipStream) Close() error {
+	s.body.Close()
+	return s.pipeStream.Close()
+}
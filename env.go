@@ -0,0 +1,115 @@
+package xreq
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewClientFromEnv return a Client configured by the environment
+// variables with the prefix, default "XREQ", opt are the default
+// options of the requests as NewClient. The variables are:
+//
+//	XREQ_TIMEOUT                  the Config.Timeout, such as "5s"
+//	XREQ_DIAL_TIMEOUT             the Config.DialTimeout
+//	XREQ_TLS_HANDSHAKE_TIMEOUT    the Config.TLSHandshakeTimeout
+//	XREQ_RESPONSE_HEADER_TIMEOUT  the Config.ResponseHeaderTimeout
+//	XREQ_IDLE_CONN_TIMEOUT        the Config.IdleConnTimeout
+//	XREQ_PROXY                    the proxy URL, default from the HTTP_PROXY
+//	XREQ_TLS_CA_FILE              the TLSFiles.CAFile
+//	XREQ_TLS_CERT_FILE            the TLSFiles.CertFile
+//	XREQ_TLS_KEY_FILE             the TLSFiles.KeyFile
+//	XREQ_TLS_INSECURE             the TLSFiles.InsecureSkipVerify, such as "true"
+//	XREQ_RETRY_MAX_ATTEMPTS       the Config.Retry.MaxAttempts
+//	XREQ_RETRY_BACKOFF            the Config.Retry.Backoff
+//	XREQ_RETRY_TIMEOUT            the Config.Retry.Timeout
+//	XREQ_BASE_URLS                the Config.BaseURLs separated by commas
+//	XREQ_USER_AGENT               the Config.UserAgent
+//
+// The unset variables keep the zero values of the Config.
+func NewClientFromEnv(prefix string, opt ...Option) (*Client, error) {
+	if prefix == "" {
+		prefix = "XREQ"
+	}
+	e := envReader{prefix: prefix + "_"}
+	var conf Config
+	conf.Timeout = e.duration("TIMEOUT")
+	conf.DialTimeout = e.duration("DIAL_TIMEOUT")
+	conf.TLSHandshakeTimeout = e.duration("TLS_HANDSHAKE_TIMEOUT")
+	conf.ResponseHeaderTimeout = e.duration("RESPONSE_HEADER_TIMEOUT")
+	conf.IdleConnTimeout = e.duration("IDLE_CONN_TIMEOUT")
+	conf.Retry.MaxAttempts = e.int("RETRY_MAX_ATTEMPTS")
+	conf.Retry.Backoff = e.duration("RETRY_BACKOFF")
+	conf.Retry.Timeout = e.duration("RETRY_TIMEOUT")
+	conf.UserAgent = e.string("USER_AGENT")
+	for _, u := range strings.Split(e.string("BASE_URLS"), ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			conf.BaseURLs = append(conf.BaseURLs, u)
+		}
+	}
+	files := TLSFiles{
+		CAFile:             e.string("TLS_CA_FILE"),
+		CertFile:           e.string("TLS_CERT_FILE"),
+		KeyFile:            e.string("TLS_KEY_FILE"),
+		InsecureSkipVerify: e.bool("TLS_INSECURE"),
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	t, err := newTransport(e.string("PROXY"), files)
+	if err != nil {
+		return nil, err
+	}
+	conf.Transport = t
+	return NewClient(conf, opt...), nil
+}
+
+// envReader reads the variables with the prefix,
+// the first parse error is kept.
+type envReader struct {
+	prefix string
+	err    error
+}
+
+func (e *envReader) string(key string) string {
+	return strings.TrimSpace(os.Getenv(e.prefix + key))
+}
+
+func (e *envReader) duration(key string) time.Duration {
+	v := e.string(key)
+	if v == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	e.fail(key, err)
+	return d
+}
+
+func (e *envReader) int(key string) int {
+	v := e.string(key)
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	e.fail(key, err)
+	return n
+}
+
+func (e *envReader) bool(key string) bool {
+	v := e.string(key)
+	if v == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	e.fail(key, err)
+	return b
+}
+
+func (e *envReader) fail(key string, err error) {
+	if err != nil && e.err == nil {
+		e.err = fmt.Errorf("parse %s%s error: %w", e.prefix, key, err)
+	}
+}
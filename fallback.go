@@ -0,0 +1,75 @@
+package xreq
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	urlpkg "net/url"
+)
+
+// WithFallbackHosts set the backup hosts such as "https://backup.example.com",
+// the request is sent to the next host with the same path and query
+// on a connection failure or a 5xx response of the previous host,
+// the chosen host is recorded in the Result.Host.
+// NOTE the request body is replayed via GetBody, a request whose body
+// can't be replayed doesn't fall back.
+func WithFallbackHosts(urls ...string) Option {
+	return func(o *Options) {
+		o.fallbacks = urls
+	}
+}
+
+// sendFallback send the request of opts to the primary host
+// and then the fallback hosts until one succeeds.
+func (c *Client) sendFallback(hc *http.Client, opts *Options) (*http.Response, error) {
+	resp, err := c.sendRetry(hc, opts)
+	req := opts.Request
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	for _, host := range opts.fallbacks {
+		if !replayable || req.Context().Err() != nil || !shouldFallback(resp, err) {
+			break
+		}
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if opts.Request, err = fallbackRequest(req, host); err != nil {
+			return nil, err
+		}
+		if err = c.hosts.check(opts.Request.URL.Hostname()); err != nil {
+			return nil, err
+		}
+		// the signatures may cover the scheme and the host.
+		if err = opts.sign(opts.Request); err != nil {
+			return nil, err
+		}
+		resp, err = c.sendRetry(hc, opts)
+	}
+	return resp, err
+}
+
+func shouldFallback(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// fallbackRequest return a clone of req sent to the scheme and host of base.
+func fallbackRequest(req *http.Request, base string) (*http.Request, error) {
+	u, err := urlpkg.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("parse fallback host error: %w", err)
+	}
+	freq := req.Clone(req.Context())
+	freq.URL.Scheme = u.Scheme
+	freq.URL.Host = u.Host
+	if req.Host == "" || req.Host == req.URL.Host {
+		freq.Host = u.Host
+	}
+	if req.GetBody != nil {
+		if freq.Body, err = req.GetBody(); err != nil {
+			return nil, fmt.Errorf("get body error: %w", err)
+		}
+	}
+	return freq, nil
+}
@@ -0,0 +1,61 @@
+package xreq
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// WithBodyFile stream the file of path as the request body,
+// the Content-Length is set from the file size and the file
+// is opened at the first read, so a request which is never sent
+// doesn't leak it, and it's reopened to replay the body.
+func WithBodyFile(contentType, path string) Option {
+	return func(o *Options) {
+		fi, err := os.Stat(path)
+		if err != nil {
+			o.Err = fmt.Errorf("stat file error: %w", err)
+			return
+		}
+
+		req := o.Request
+		req.Header.Set("Content-Type", contentType)
+		req.Body = &lazyFile{path: path}
+		req.ContentLength = fi.Size()
+		req.GetBody = func() (io.ReadCloser, error) {
+			return &lazyFile{path: path}, nil
+		}
+		o.setBodyOption("WithBodyFile")
+	}
+}
+
+// lazyFile opens the file of path at the first read.
+type lazyFile struct {
+	path string
+	once sync.Once
+	f    *os.File
+	err  error
+}
+
+func (l *lazyFile) Read(p []byte) (int, error) {
+	l.once.Do(func() {
+		if l.f, l.err = os.Open(l.path); l.err != nil {
+			l.err = fmt.Errorf("open file error: %w", l.err)
+		}
+	})
+	if l.err != nil {
+		return 0, l.err
+	}
+	return l.f.Read(p)
+}
+
+func (l *lazyFile) Close() error {
+	l.once.Do(func() {
+		l.err = os.ErrClosed
+	})
+	if l.f != nil {
+		return l.f.Close()
+	}
+	return nil
+}
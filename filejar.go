@@ -0,0 +1,190 @@
+package xreq
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	urlpkg "net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileJar is a http.CookieJar persisted in a file, so the sessions
+// survive the restarts of CLI tools and CI jobs. The file is encrypted
+// by AES-GCM if a key is given, otherwise it's plaintext JSON.
+// It's safe for concurrent use in a process.
+type FileJar struct {
+	*cookiejar.Jar
+
+	path string
+	aead cipher.AEAD
+
+	mu      sync.Mutex
+	entries map[string]fileJarEntry
+}
+
+// fileJarEntry is a cookie set for the URL.
+type fileJarEntry struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// NewFileJar return a FileJar loaded from the file of path if it exists,
+// the key of 16, 24 or 32 bytes selects AES-128, AES-192 or AES-256
+// to encrypt the file, a nil key stores it in plaintext.
+// Call Save to write the cookies back.
+func NewFileJar(path string, key []byte) (*FileJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, fmt.Errorf("new cookie jar error: %w", err)
+	}
+	j := &FileJar{
+		Jar:     jar,
+		path:    path,
+		entries: make(map[string]fileJarEntry),
+	}
+	if key != nil {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("new cipher error: %w", err)
+		}
+		if j.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, fmt.Errorf("new gcm error: %w", err)
+		}
+	}
+	if err = j.load(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// SetCookies implements http.CookieJar.
+func (j *FileJar) SetCookies(u *urlpkg.URL, cookies []*http.Cookie) {
+	j.Jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	for _, c := range cookies {
+		c := *c
+		// MaxAge is relative to the time it's received.
+		if c.MaxAge > 0 {
+			c.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+			c.MaxAge = 0
+		}
+		e := fileJarEntry{URL: u.Scheme + "://" + u.Host + u.Path, Cookie: &c}
+		key := cookieID(u, &c)
+		if c.MaxAge < 0 || (!c.Expires.IsZero() && !c.Expires.After(now)) {
+			delete(j.entries, key)
+			continue
+		}
+		j.entries[key] = e
+	}
+}
+
+// cookieID return the identity of c set for u like the cookiejar,
+// the effective domain, the effective path and the name, so a cookie
+// is replaced or deleted from any URL it applies to.
+func cookieID(u *urlpkg.URL, c *http.Cookie) string {
+	domain := strings.ToLower(strings.TrimPrefix(c.Domain, "."))
+	if domain == "" {
+		domain = strings.ToLower(u.Hostname())
+	}
+	path := c.Path
+	if path == "" || path[0] != '/' {
+		// the default path of RFC 6265 5.1.4.
+		path = "/"
+		if i := strings.LastIndex(u.Path, "/"); i > 0 {
+			path = u.Path[:i]
+		}
+	}
+	return domain + ";" + path + ";" + c.Name
+}
+
+// Save write the cookies into the file,
+// the expired ones are dropped.
+func (j *FileJar) Save() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	entries := make([]fileJarEntry, 0, len(j.entries))
+	for key, e := range j.entries {
+		if !e.Cookie.Expires.IsZero() && !e.Cookie.Expires.After(now) {
+			delete(j.entries, key)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("json marshal error: %w", err)
+	}
+	if j.aead != nil {
+		nonce := make([]byte, j.aead.NonceSize())
+		if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+			return fmt.Errorf("read nonce error: %w", err)
+		}
+		data = j.aead.Seal(nonce, nonce, data, nil)
+	}
+
+	// write to a temp file then rename, so a crash never leaves a partial file.
+	f, err := ioutil.TempFile(filepath.Dir(j.path), ".tmp-")
+	if err != nil {
+		return fmt.Errorf("create file error: %w", err)
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), j.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("write file error: %w", err)
+	}
+	return nil
+}
+
+// load read the cookies from the file if it exists.
+func (j *FileJar) load() error {
+	data, err := ioutil.ReadFile(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read file error: %w", err)
+	}
+	if j.aead != nil {
+		size := j.aead.NonceSize()
+		if len(data) < size {
+			return errors.New("decrypt cookies error: file too short")
+		}
+		if data, err = j.aead.Open(nil, data[:size], data[size:], nil); err != nil {
+			return fmt.Errorf("decrypt cookies error: %w", err)
+		}
+	}
+
+	var entries []fileJarEntry
+	if err = json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("json unmarshal error: %w", err)
+	}
+	for _, e := range entries {
+		u, err := urlpkg.Parse(e.URL)
+		if err != nil || e.Cookie == nil {
+			continue
+		}
+		j.SetCookies(u, []*http.Cookie{e.Cookie})
+	}
+	return nil
+}
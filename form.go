@@ -0,0 +1,173 @@
+package xreq
+
+import (
+	"encoding"
+	"fmt"
+	urlpkg "net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// WithPostFormStruct encode the struct v as the post form.
+// The name of the field is taken from the `form` tag,
+// or the field name if the tag is absent, `form:"-"` skips the field.
+// The "omitempty" option skips the field with zero value,
+// the time.Time is formatted by the `time_format` tag (default RFC3339)
+// and the slice is encoded as multiple values of the same key.
+//
+// Example:
+//
+// type User struct {
+//     Name     string    `form:"name"`
+//     Age      int       `form:"age,omitempty"`
+//     Birthday time.Time `form:"birthday" time_format:"2006-01-02"`
+// }
+func WithPostFormStruct(v interface{}) Option {
+	return func(o *Options) {
+		vals := make(urlpkg.Values)
+		if err := encodeForm(vals, reflect.ValueOf(v)); err != nil {
+			o.Err = fmt.Errorf("form encode error: %w", err)
+			return
+		}
+		setPostForm(o.Request, vals)
+		o.setBodyOption("WithPostFormStruct")
+	}
+}
+
+func encodeForm(vals urlpkg.Values, rv reflect.Value) error {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return fmt.Errorf("nil pointer of %s", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("expected struct, got %s", rv.Kind())
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag := sf.Tag.Get("form")
+		if tag == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+		if sf.Anonymous && tag == "" {
+			// like encoding/json, the fields of an embedded struct are promoted,
+			// but an unexported one only if it's a non-pointer struct,
+			// and the time.Time and the TextMarshaler are values.
+			ft := indirectType(sf.Type)
+			if sf.PkgPath != "" && (sf.Type.Kind() == reflect.Ptr || ft.Kind() != reflect.Struct) {
+				continue
+			}
+			if ft.Kind() == reflect.Struct && !isFormValue(ft) {
+				if fv.Kind() == reflect.Ptr && fv.IsNil() {
+					continue
+				}
+				if err := encodeForm(vals, fv); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		name, opts := parseTag(tag)
+		if name == "" {
+			name = sf.Name
+		}
+		if strings.Contains(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+		if err := addFormValue(vals, name, fv, sf.Tag.Get("time_format")); err != nil {
+			return fmt.Errorf("field %s: %w", sf.Name, err)
+		}
+	}
+	return nil
+}
+
+func addFormValue(vals urlpkg.Values, name string, fv reflect.Value, layout string) error {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 || fv.Kind() == reflect.Array {
+		for i := 0; i < fv.Len(); i++ {
+			if err := addFormValue(vals, name, fv.Index(i), layout); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	s, err := formatFormValue(fv, layout)
+	if err != nil {
+		return err
+	}
+	vals.Add(name, s)
+	return nil
+}
+
+// isFormValue reports whether the struct type t is encoded as a value.
+func isFormValue(t reflect.Type) bool {
+	return t == timeType || t.Implements(textMarshalerType)
+}
+
+func formatFormValue(fv reflect.Value, layout string) (string, error) {
+	if isFormValue(fv.Type()) && !fv.CanInterface() {
+		return "", fmt.Errorf("unexported type %s", fv.Type())
+	}
+	if fv.Type() == timeType {
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return fv.Interface().(time.Time).Format(layout), nil
+	}
+	if fv.Type().Implements(textMarshalerType) {
+		text, err := fv.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(fv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, fv.Type().Bits()), nil
+	case reflect.Slice:
+		return string(fv.Bytes()), nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", fv.Type())
+	}
+}
+
+func parseTag(tag string) (name, opts string) {
+	if i := strings.Index(tag, ","); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
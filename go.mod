@@ -1,5 +1,17 @@
 module github.com/ehyyoj/xreq
 
-go 1.14
+go 1.24
 
-require github.com/stretchr/testify v1.6.1
+require (
+	github.com/Azure/go-ntlmssp v0.1.1
+	github.com/andybalholm/brotli v1.2.0
+	github.com/klauspost/compress v1.18.0
+	github.com/stretchr/testify v1.6.1
+	golang.org/x/sync v0.16.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
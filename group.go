@@ -0,0 +1,36 @@
+package xreq
+
+import "golang.org/x/sync/errgroup"
+
+// Go schedules the request in g with the default client, see Client.Go.
+func Go(g *errgroup.Group, url string, out interface{}, opt ...Option) {
+	defaultClient.Go(g, url, out, opt...)
+}
+
+// Go schedules the request in g and unmarshal its JSON body into out
+// unless out is nil, the status is checked as WithCheckStatus(true)
+// unless opt overrides it. Pass the context of errgroup.WithContext
+// by WithContext to cancel the other requests on the first failure.
+//
+// Example:
+//
+// g, ctx := errgroup.WithContext(ctx)
+// var user User
+// var orders []Order
+// cli.Go(g, "http://localhost/user", &user, WithContext(ctx))
+// cli.Go(g, "http://localhost/orders", &orders, WithContext(ctx))
+// err := g.Wait()
+func (c *Client) Go(g *errgroup.Group, url string, out interface{}, opt ...Option) {
+	opt = append([]Option{WithCheckStatus(true)}, opt...)
+	g.Go(func() error {
+		resp, err := c.DoR(url, opt...)
+		if err != nil {
+			return err
+		}
+		if out == nil {
+			_, err = resp.Bytes()
+			return err
+		}
+		return resp.JSON(out)
+	})
+}
@@ -0,0 +1,89 @@
+package xreq
+
+import (
+	"fmt"
+	urlpkg "net/url"
+	"strconv"
+	"strings"
+)
+
+// WithAccept set the Accept header to the media type.
+func WithAccept(mime string) Option {
+	return WithSetHeader("Accept", mime)
+}
+
+// WithAcceptJSON set the Accept header to "application/json".
+func WithAcceptJSON() Option {
+	return WithAccept("application/json")
+}
+
+// WithAcceptList set the Accept header to the media types in order of
+// preference, weighted by the decreasing quality values, e.g.
+// WithAcceptList("application/json", "application/xml", "*/*") sets
+// "application/json, application/xml;q=0.9, */*;q=0.8".
+func WithAcceptList(mimes ...string) Option {
+	vals := make([]string, len(mimes))
+	for i, mime := range mimes {
+		q := 10 - i
+		if q < 1 {
+			q = 1
+		}
+		if i == 0 {
+			vals[i] = mime
+		} else {
+			vals[i] = mime + ";q=0." + strconv.Itoa(q)
+		}
+	}
+	return WithAccept(strings.Join(vals, ", "))
+}
+
+// WithReferer set the Referer header to the URL
+// without the fragment and the userinfo.
+func WithReferer(url string) Option {
+	return func(o *Options) {
+		u, err := urlpkg.Parse(url)
+		if err != nil {
+			o.Err = fmt.Errorf("parse referer error: %w", err)
+			return
+		}
+		u.Fragment = ""
+		u.RawFragment = ""
+		u.User = nil
+		o.Request.Header.Set("Referer", u.String())
+	}
+}
+
+// WithOrigin set the Origin header to the scheme and host of the URL,
+// e.g. "https://example.com/app" sets "https://example.com".
+func WithOrigin(url string) Option {
+	return func(o *Options) {
+		u, err := urlpkg.Parse(url)
+		if err != nil {
+			o.Err = fmt.Errorf("parse origin error: %w", err)
+			return
+		}
+		if u.Scheme == "" || u.Host == "" {
+			o.Err = fmt.Errorf("invalid origin: %q", url)
+			return
+		}
+		o.Request.Header.Set("Origin", u.Scheme+"://"+u.Host)
+	}
+}
+
+// WithForwardedFor append the client IPs to the X-Forwarded-For header,
+// e.g. forwarding the X-Forwarded-For of the incoming request
+// with the address of its client.
+func WithForwardedFor(ips ...string) Option {
+	return func(o *Options) {
+		vals := make([]string, 0, len(ips)+1)
+		if prior := o.Request.Header.Get("X-Forwarded-For"); prior != "" {
+			vals = append(vals, prior)
+		}
+		for _, ip := range ips {
+			if ip = strings.TrimSpace(ip); ip != "" {
+				vals = append(vals, ip)
+			}
+		}
+		o.Request.Header.Set("X-Forwarded-For", strings.Join(vals, ", "))
+	}
+}
@@ -0,0 +1,147 @@
+package xreq
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthCheck defines the active health checks of the Config.BaseURLs,
+// the endpoints failed the probe are not picked until they recover,
+// all endpoints are picked if none is healthy.
+type HealthCheck struct {
+	// Path is the path of the probe joined to each base URL, e.g. "/healthz".
+	Path string
+	// Interval is the interval between the probes, default 10 seconds.
+	Interval time.Duration
+	// Timeout is the timeout of a probe, default 2 seconds.
+	Timeout time.Duration
+	// Healthy reports whether the probe response is healthy,
+	// default a 2xx status.
+	Healthy func(resp *http.Response) bool
+}
+
+// healthChecker probes the base URLs and keeps their states.
+type healthChecker struct {
+	conf HealthCheck
+	urls func() []string
+	hc   *http.Client
+
+	mu   sync.RWMutex
+	down map[string]bool
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+func newHealthChecker(conf HealthCheck, urls func() []string, transport http.RoundTripper) *healthChecker {
+	if conf.Interval <= 0 {
+		conf.Interval = 10 * time.Second
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = 2 * time.Second
+	}
+	if conf.Healthy == nil {
+		conf.Healthy = func(resp *http.Response) bool {
+			return resp.StatusCode >= 200 && resp.StatusCode < 300
+		}
+	}
+	h := &healthChecker{
+		conf: conf,
+		urls: urls,
+		hc:   &http.Client{Transport: transport, Timeout: conf.Timeout},
+		down: make(map[string]bool),
+		stop: make(chan struct{}),
+	}
+	h.wg.Add(1)
+	go h.run()
+	return h
+}
+
+func (h *healthChecker) run() {
+	defer h.wg.Done()
+	t := time.NewTicker(h.conf.Interval)
+	defer t.Stop()
+	for {
+		h.probeAll()
+		select {
+		case <-h.stop:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (h *healthChecker) probeAll() {
+	urls := h.urls()
+	down := make(map[string]bool, len(urls))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, u := range urls {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			if !h.probe(u) {
+				mu.Lock()
+				down[u] = true
+				mu.Unlock()
+			}
+		}(u)
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	h.down = down
+	h.mu.Unlock()
+}
+
+func (h *healthChecker) probe(base string) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-h.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, joinBaseURL(base, h.conf.Path), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := h.hc.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	return h.conf.Healthy(resp)
+}
+
+// healthy return the healthy ones of the urls, or all if none is healthy.
+func (h *healthChecker) healthy(urls []string) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	up := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if !h.down[u] {
+			up = append(up, u)
+		}
+	}
+	if len(up) == 0 {
+		return urls
+	}
+	return up
+}
+
+func (h *healthChecker) close() {
+	h.once.Do(func() {
+		close(h.stop)
+	})
+	h.wg.Wait()
+}
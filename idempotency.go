@@ -0,0 +1,39 @@
+package xreq
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// WithIdempotencyKey set the Idempotency-Key header with a random UUID,
+// as required by Stripe-style APIs to make a POST safe to retry.
+// The key is generated once per request, the resends of the request
+// (such as the reauth or retries) share the same key.
+func WithIdempotencyKey() Option {
+	return func(o *Options) {
+		key, err := newUUID()
+		if err != nil {
+			o.Err = fmt.Errorf("generate idempotency key error: %w", err)
+			return
+		}
+		o.Request.Header.Set("Idempotency-Key", key)
+	}
+}
+
+// WithIdempotencyKeyValue set the Idempotency-Key header with key.
+func WithIdempotencyKeyValue(key string) Option {
+	return func(o *Options) {
+		o.Request.Header.Set("Idempotency-Key", key)
+	}
+}
+
+// newUUID return a random (version 4) UUID.
+func newUUID() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", err
+	}
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:]), nil
+}
@@ -0,0 +1,121 @@
+package xreq
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DoJSONStream send the request with the default client
+// and decode the JSON body element by element, see DoJSONStreamWith.
+func DoJSONStream[T any](url string, opt ...Option) (<-chan T, <-chan error) {
+	return DoJSONStreamWith[T](&defaultClient, url, opt...)
+}
+
+// DoJSONStreamWith send the request with c and decode the elements of
+// the top-level JSON array, or the values of the NDJSON, of the body
+// into the first channel one by one, so the body doesn't need to fit
+// in memory. The error channel receives at most one error and both
+// channels are closed when the body is done. The status is checked as
+// WithCheckStatus(true) unless opt overrides it.
+// NOTE the elements must be received until the channel is closed,
+// or the request is canceled by the context given by WithContext.
+//
+// Example:
+//
+// items, errc := xreq.DoJSONStreamWith[Item](cli, "http://localhost/items")
+// for item := range items {
+//     ...
+// }
+// if err := <-errc; err != nil {
+//     ...
+// }
+func DoJSONStreamWith[T any](c *Client, url string, opt ...Option) (<-chan T, <-chan error) {
+	out := make(chan T)
+	errc := make(chan error, 1)
+	opt = append([]Option{WithCheckStatus(true)}, opt...)
+	go func() {
+		defer close(errc)
+		defer close(out)
+
+		opts := &Options{}
+		resp, err := c.do(opts, url, opt...)
+		if err != nil {
+			errc <- err
+			return
+		}
+		if checkStatus(opts, resp, nil) != nil {
+			_, err = readBody(opts, resp)
+			errc <- err
+			return
+		}
+		defer resp.Body.Close()
+
+		if err = decodeJSONStream(resp, out); err != nil {
+			errc <- requestError(resp.Request, opts.redactQuery, err)
+			return
+		}
+		if err = checkTrailers(resp, opts.trailers); err != nil {
+			errc <- requestError(resp.Request, opts.redactQuery, err)
+		}
+	}()
+	return out, errc
+}
+
+// decodeJSONStream decode the JSON array or the NDJSON of the body
+// of resp into out.
+func decodeJSONStream[T any](resp *http.Response, out chan<- T) error {
+	ctx := resp.Request.Context()
+	br := bufio.NewReader(resp.Body)
+	array, err := peekArray(br)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read body error: %w", err)
+	}
+
+	dec := json.NewDecoder(br)
+	if array {
+		if _, err = dec.Token(); err != nil {
+			return fmt.Errorf("json decode error: %w", err)
+		}
+	}
+	for !array || dec.More() {
+		var v T
+		err = dec.Decode(&v)
+		if err == io.EOF && !array {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("json decode error: %w", err)
+		}
+		select {
+		case out <- v:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if _, err = dec.Token(); err != nil {
+		return fmt.Errorf("json decode error: %w", err)
+	}
+	return nil
+}
+
+// peekArray skip the leading whitespaces of br
+// and report whether the JSON is an array.
+func peekArray(br *bufio.Reader) (bool, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return false, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b == '[', br.UnreadByte()
+	}
+}
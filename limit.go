@@ -0,0 +1,53 @@
+package xreq
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrBodyTooLarge is returned when the request body
+// exceeds the Config.MaxRequestBodyBytes.
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// limitBody fails req if its body exceeds max bytes, a body of unknown
+// length fails once the limit is exceeded while it's being sent.
+func limitBody(req *http.Request, max int64) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	if req.ContentLength > max {
+		return fmt.Errorf("%w: %d > %d bytes", ErrBodyTooLarge, req.ContentLength, max)
+	}
+	if req.ContentLength > 0 {
+		return nil
+	}
+
+	req.Body = &limitedBody{ReadCloser: req.Body, n: max}
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return &limitedBody{ReadCloser: body, n: max}, nil
+		}
+	}
+	return nil
+}
+
+// limitedBody fails the read once more than n bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n -= int64(n)
+	if b.n < 0 {
+		return n, ErrBodyTooLarge
+	}
+	return n, err
+}
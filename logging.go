@@ -0,0 +1,95 @@
+package xreq
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LogOptions defines the logging of NewLoggingTransport.
+type LogOptions struct {
+	// Headers logs the headers of the requests and the responses.
+	Headers bool
+	// Redaction is the headers and the query params masked in the logs.
+	Redaction Redaction
+}
+
+// sensitiveHeaders are always masked in the logs and the dumps.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// NewLoggingTransport return a http.RoundTripper logging every request
+// sent by base to logger, base is http.DefaultTransport if nil.
+// It's independent of the Client, so it can wrap the http.Client of any
+// SDK. The requests are logged at the Info level, or the Error level
+// if they fail.
+//
+// Example:
+//
+// hc := &http.Client{
+//     Transport: xreq.NewLoggingTransport(nil, slog.Default(), xreq.LogOptions{}),
+// }
+func NewLoggingTransport(base http.RoundTripper, logger *slog.Logger, opts LogOptions) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &loggingTransport{base: base, logger: logger, opts: opts}
+}
+
+type loggingTransport struct {
+	base   http.RoundTripper
+	logger *slog.Logger
+	opts   LogOptions
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", redactURL(req.URL, t.opts.Redaction.Query)),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if route := RouteFromContext(req.Context()); route != "" {
+		attrs = append(attrs, slog.String("route", route))
+	}
+	if t.opts.Headers {
+		attrs = append(attrs, slog.Any("request_headers", redactHeader(req.Header, t.opts.Redaction.Headers, true)))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		t.logger.LogAttrs(req.Context(), slog.LevelError, "http request", attrs...)
+		return nil, err
+	}
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	if t.opts.Headers {
+		attrs = append(attrs, slog.Any("response_headers", redactHeader(resp.Header, t.opts.Redaction.Headers, true)))
+	}
+	t.logger.LogAttrs(req.Context(), slog.LevelInfo, "http request", attrs...)
+	return resp, nil
+}
+
+// redactHeader return a copy of h with the extra headers masked,
+// along with the sensitiveHeaders if sensitive.
+func redactHeader(h http.Header, extra []string, sensitive bool) http.Header {
+	h = h.Clone()
+	for k := range h {
+		if sensitive && containsFold(sensitiveHeaders, k) || containsFold(extra, k) {
+			h[k] = []string{redacted}
+		}
+	}
+	return h
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
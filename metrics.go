@@ -0,0 +1,74 @@
+package xreq
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestMetrics are the metrics of a request sent by the transport
+// of NewMetricsTransport.
+type RequestMetrics struct {
+	Method string
+	Host   string
+	// Route is the route template given by WithRoute, the raw paths are
+	// left out so the collectors don't explode on the cardinality.
+	Route string
+	// StatusCode is zero if the request fails.
+	StatusCode int
+	// Duration is the time up to the response headers.
+	Duration time.Duration
+	Err      error
+}
+
+// MetricsCollector collects the metrics of the requests,
+// it may be invoked concurrently.
+type MetricsCollector interface {
+	ObserveRequest(m RequestMetrics)
+}
+
+// MetricsCollectorFunc is an adapter to use a function as a MetricsCollector.
+type MetricsCollectorFunc func(m RequestMetrics)
+
+// ObserveRequest implements MetricsCollector.
+func (f MetricsCollectorFunc) ObserveRequest(m RequestMetrics) {
+	f(m)
+}
+
+// NewMetricsTransport return a http.RoundTripper reporting the metrics
+// of every request sent by base to collector, base is
+// http.DefaultTransport if nil. It's independent of the Client,
+// so it can instrument any http.Client.
+//
+// Example:
+//
+// hc := &http.Client{
+//     Transport: xreq.NewMetricsTransport(nil, collector),
+// }
+func NewMetricsTransport(base http.RoundTripper, collector MetricsCollector) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &metricsTransport{base: base, collector: collector}
+}
+
+type metricsTransport struct {
+	base      http.RoundTripper
+	collector MetricsCollector
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	m := RequestMetrics{
+		Method:   req.Method,
+		Host:     req.URL.Host,
+		Route:    RouteFromContext(req.Context()),
+		Duration: time.Since(start),
+		Err:      err,
+	}
+	if resp != nil {
+		m.StatusCode = resp.StatusCode
+	}
+	t.collector.ObserveRequest(m)
+	return resp, err
+}
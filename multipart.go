@@ -0,0 +1,102 @@
+package xreq
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// MultipartPart is a part of the multipart/form-data body
+// whose value is streamed from the Reader, see WithMultipartParts.
+type MultipartPart struct {
+	// Name is the form field name.
+	Name string
+	// FileName is the file name of a file part, empty for a field.
+	FileName string
+	// ContentType is the Content-Type of the part, default
+	// "application/octet-stream" for a file part and none for a field.
+	ContentType string
+	Reader      io.Reader
+	// Size is the bytes of the Reader, which makes the Content-Length
+	// of the body known without reading it, zero or negative means
+	// unknown and the body is sent chunked then.
+	Size int64
+}
+
+// WithMultipartParts stream the parts as the multipart/form-data body
+// without buffering them, so the large values such as an embedded CSV
+// don't need to be in memory. The Content-Length is set if the sizes
+// of all parts are declared, the request must not declare a size
+// different from the bytes of the Reader.
+// NOTE the body can't be replayed, so the request is not retried.
+//
+// Example:
+//
+// data, _, err := xreq.DoBytes(url,
+//			WithMultipartParts(
+//				xreq.MultipartPart{Name: "name", Reader: strings.NewReader("jack"), Size: 4},
+//				xreq.MultipartPart{Name: "csv", Reader: csv, Size: size}))
+func WithMultipartParts(parts ...MultipartPart) Option {
+	return func(o *Options) {
+		buf := new(bytes.Buffer)
+		writer := multipart.NewWriter(buf)
+		readers := make([]io.Reader, 0, len(parts)*2+1)
+		length, known := int64(0), true
+		for _, p := range parts {
+			if p.Reader == nil {
+				o.Err = fmt.Errorf("invalid multipart part: %q has no reader", p.Name)
+				return
+			}
+			if _, err := writer.CreatePart(partHeader(p)); err != nil {
+				o.Err = fmt.Errorf("create part error: %w", err)
+				return
+			}
+			// the boundary and the headers of the part precede its value.
+			head := append([]byte(nil), buf.Bytes()...)
+			buf.Reset()
+			readers = append(readers, bytes.NewReader(head), p.Reader)
+			length += int64(len(head)) + p.Size
+			known = known && p.Size > 0
+		}
+		if err := writer.Close(); err != nil {
+			o.Err = fmt.Errorf("writer close error: %w", err)
+			return
+		}
+		readers = append(readers, bytes.NewReader(buf.Bytes()))
+		length += int64(buf.Len())
+		if !known {
+			length = -1
+		}
+
+		req := o.Request
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Method = http.MethodPost
+		req.Body = ioutil.NopCloser(io.MultiReader(readers...))
+		req.ContentLength = length
+		req.GetBody = nil
+		o.setBodyOption("WithMultipartParts")
+	}
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// partHeader return the MIME header of p like the multipart.Writer.
+func partHeader(p MultipartPart) textproto.MIMEHeader {
+	h := make(textproto.MIMEHeader)
+	disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(p.Name))
+	if p.FileName != "" {
+		disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(p.FileName))
+	}
+	h.Set("Content-Disposition", disposition)
+	if p.ContentType != "" {
+		h.Set("Content-Type", p.ContentType)
+	} else if p.FileName != "" {
+		h.Set("Content-Type", "application/octet-stream")
+	}
+	return h
+}
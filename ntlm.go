@@ -0,0 +1,34 @@
+package xreq
+
+import "github.com/Azure/go-ntlmssp"
+
+// NTLMProvider is an AuthProvider of the NTLMv2 authentication,
+// which is common for the Windows-authenticated proxies and IIS endpoints.
+type NTLMProvider struct {
+	// Username can be "DOMAIN\user", "user@domain" or "user".
+	Username string
+	Password string
+	// UseNegotiate use the "Negotiate" scheme to carry the NTLM tokens
+	// instead of "NTLM", for the servers only accept Negotiate.
+	UseNegotiate bool
+}
+
+// Scheme implements AuthProvider.
+func (p *NTLMProvider) Scheme() string {
+	if p.UseNegotiate {
+		return "Negotiate"
+	}
+	return "NTLM"
+}
+
+// Negotiate implements AuthProvider.
+func (p *NTLMProvider) Negotiate() ([]byte, error) {
+	_, domain, _ := ntlmssp.GetDomain(p.Username)
+	return ntlmssp.NewNegotiateMessage(domain, "")
+}
+
+// Challenge implements AuthProvider.
+func (p *NTLMProvider) Challenge(challenge []byte) ([]byte, error) {
+	user, _, domainNeeded := ntlmssp.GetDomain(p.Username)
+	return ntlmssp.ProcessChallenge(challenge, user, p.Password, domainNeeded)
+}
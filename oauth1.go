@@ -0,0 +1,143 @@
+package xreq
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	urlpkg "net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OAuth1 signs the request with OAuth 1.0a HMAC-SHA1,
+// which is still required by APIs like Twitter, Flickr and NetSuite.
+// Set it into Config.OAuth1 to sign all requests of the Client,
+// or use WithSigner(o.Sign) to sign a single request.
+type OAuth1 struct {
+	ConsumerKey    string
+	ConsumerSecret string
+	Token          string
+	TokenSecret    string
+
+	// Now return the current time, default time.Now.
+	Now func() time.Time
+	// Nonce return the oauth_nonce, default a random hex string.
+	Nonce func() string
+}
+
+// Sign set the OAuth Authorization header into req, the query and the
+// x-www-form-urlencoded body params are covered by the signature.
+func (o *OAuth1) Sign(req *http.Request) error {
+	now, nonce := time.Now, func() string { return randomHex(16) }
+	if o.Now != nil {
+		now = o.Now
+	}
+	if o.Nonce != nil {
+		nonce = o.Nonce
+	}
+
+	oauth := map[string]string{
+		"oauth_consumer_key":     o.ConsumerKey,
+		"oauth_nonce":            nonce(),
+		"oauth_signature_method": "HMAC-SHA1",
+		"oauth_timestamp":        strconv.FormatInt(now().Unix(), 10),
+		"oauth_version":          "1.0",
+	}
+	if o.Token != "" {
+		oauth["oauth_token"] = o.Token
+	}
+
+	params := req.URL.Query()
+	form, err := formParams(req)
+	if err != nil {
+		return err
+	}
+	for k, vs := range form {
+		params[k] = append(params[k], vs...)
+	}
+	for k, v := range oauth {
+		params.Set(k, v)
+	}
+
+	base := strings.Join([]string{
+		strings.ToUpper(req.Method),
+		oauthEscape(oauthBaseURL(req.URL)),
+		oauthEscape(oauthParams(params)),
+	}, "&")
+	mac := hmac.New(sha1.New, []byte(oauthEscape(o.ConsumerSecret)+"&"+oauthEscape(o.TokenSecret)))
+	mac.Write([]byte(base))
+	oauth["oauth_signature"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	keys := make([]string, 0, len(oauth))
+	for k := range oauth {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, oauthEscape(k), oauthEscape(oauth[k]))
+	}
+	req.Header.Set("Authorization", "OAuth "+strings.Join(pairs, ", "))
+	return nil
+}
+
+// formParams return the params of the x-www-form-urlencoded body.
+func formParams(req *http.Request) (urlpkg.Values, error) {
+	ct, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if ct != "application/x-www-form-urlencoded" || req.GetBody == nil {
+		return nil, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("get body error: %w", err)
+	}
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("read body error: %w", err)
+	}
+	return urlpkg.ParseQuery(string(data))
+}
+
+// oauthBaseURL return the base string URI of RFC 5849 section 3.4.1.2.
+func oauthBaseURL(u *urlpkg.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Host)
+	if port := u.Port(); scheme == "http" && port == "80" || scheme == "https" && port == "443" {
+		host = strings.ToLower(u.Hostname())
+	}
+	return scheme + "://" + host + u.EscapedPath()
+}
+
+// oauthParams return the normalized parameters of RFC 5849 section 3.4.1.3.2.
+func oauthParams(params urlpkg.Values) string {
+	pairs := make([]string, 0, len(params))
+	for k, vs := range params {
+		for _, v := range vs {
+			pairs = append(pairs, oauthEscape(k)+"="+oauthEscape(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// oauthEscape percent-encodes s except the unreserved characters of RFC 3986.
+func oauthEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -11,11 +11,23 @@ import (
 	"net/http"
 	urlpkg "net/url"
 	"strings"
+	"time"
 )
 
 // Option is a type define use for pass closure as parameters.
 type Option func(o *Options)
 
+// apply invokes the option on o, a panic of the option
+// is recovered and set as o.Err.
+func (opt Option) apply(o *Options) {
+	defer func() {
+		if r := recover(); r != nil {
+			o.Err = fmt.Errorf("option panic: %v", r)
+		}
+	}()
+	opt(o)
+}
+
 // Options define some option of HTTP.
 type Options struct {
 	*http.Request
@@ -24,6 +36,68 @@ type Options struct {
 	Values urlpkg.Values
 
 	checkStatus bool
+	result      *Result
+	gzipBody    bool
+	gzipLevel   int
+	trailers    []string
+	arrayStyle  ArrayStyle
+	keepOrder   bool
+	queryKeys   []string
+	pathParams  map[string]string
+	reauth      func(ctx context.Context) (string, error)
+	signers     []func(req *http.Request) error
+
+	authProvider AuthProvider
+	traceContext bool
+	maxRedirects int
+	noRedirect   bool
+
+	redirectHooks []func(req *http.Request, via []*http.Request) error
+	redirectTrace *redirectTrace
+
+	cacheDirective cacheDirective
+	retry          RetryPolicy
+	deadlineMargin time.Duration
+	expectStatus   []int
+	errorDecoder   func(status int, body []byte) error
+	redactQuery    []string
+	fallbacks      []string
+	picked         func()
+	canary         bool
+	delHeaders     []string
+	replayBuffer   int64
+	chunked        bool
+	sniff          bool
+	jar            http.CookieJar
+	noCookies      bool
+	overrides      []Option
+	traceID        string
+	spanID         string
+	route          string
+	connHook       ConnHook
+	redaction      Redaction
+	decode         bool
+	bodyOptions    []string
+	discarded      []string
+
+	expectContinue bool
+	expectTimeout  time.Duration
+}
+
+// WithOverride defer opt until the client options and the request
+// options are applied, so opt takes precedence over both, e.g. a client
+// option that the requests can't override. The overrides are applied
+// in the order they are given, the client ones first.
+//
+// Example:
+//
+// cli := xreq.NewClient(conf,
+//     WithOverride(WithSetHeader("X-Tenant", tenant)),
+// )
+func WithOverride(opt ...Option) Option {
+	return func(o *Options) {
+		o.overrides = append(o.overrides, opt...)
+	}
 }
 
 // WithHeader set up the entire http.Header.
@@ -40,6 +114,73 @@ func WithSetHeader(k, v string) Option {
 	}
 }
 
+// WithHeaders set the headers of the map, which merges with the
+// existing headers instead of replacing them like WithHeader.
+func WithHeaders(headers map[string]string) Option {
+	return func(o *Options) {
+		for k, v := range headers {
+			o.Request.Header.Set(k, v)
+		}
+	}
+}
+
+// WithRawHeader set the header with the exact name bypassing the
+// canonicalization, for the legacy servers matching the names such as
+// "SOAPAction" case-sensitively. The header can't be read by Header.Get
+// and it's lowercased on HTTP/2 as required.
+func WithRawHeader(k, v string) Option {
+	return func(o *Options) {
+		o.Request.Header.Del(k)
+		o.Request.Header[k] = []string{v}
+	}
+}
+
+// WithDelHeader remove the header k after the defaults are merged,
+// such as the Config.BearerToken, the headers from the context
+// and the client options, e.g. suppress the Authorization
+// for a public endpoint.
+func WithDelHeader(k string) Option {
+	return func(o *Options) {
+		o.delHeaders = append(o.delHeaders, k)
+	}
+}
+
+// delHeaders remove the headers keys from req,
+// the User-Agent is kept empty so no default one is sent.
+func delHeaders(req *http.Request, keys []string) {
+	for _, k := range keys {
+		req.Header.Del(k)
+		delete(req.Header, k)
+		if http.CanonicalHeaderKey(k) == "User-Agent" {
+			req.Header["User-Agent"] = []string{""}
+		}
+	}
+}
+
+// WithAddHeader add the value to the header k,
+// which appends to the values of k rather than replaces them.
+func WithAddHeader(k, v string) Option {
+	return func(o *Options) {
+		o.Request.Header.Add(k, v)
+	}
+}
+
+// WithUserAgent set the User-Agent header,
+// which overrides the Config.UserAgent and the DefaultUserAgent.
+func WithUserAgent(ua string) Option {
+	return func(o *Options) {
+		o.Request.Header.Set("User-Agent", ua)
+	}
+}
+
+// WithHost set the Host header independently of the URL,
+// e.g. sending to a load balancer by IP with a virtual host.
+func WithHost(host string) Option {
+	return func(o *Options) {
+		o.Request.Host = host
+	}
+}
+
 // WithContext set context to the http.Request
 // it use to timeout or cancel.
 //
@@ -79,6 +220,9 @@ func WithBodyReader(contentType string, body io.Reader) Option {
 		req := o.Request
 		req.Header.Set("Content-Type", contentType)
 		setBody(req, body)
+		if body != nil {
+			o.setBodyOption("WithBodyReader")
+		}
 	}
 }
 
@@ -106,6 +250,28 @@ func setBody(req *http.Request, body io.Reader) {
 			r := snapshot
 			return ioutil.NopCloser(&r), nil
 		}
+	case io.Seeker:
+		// the length from the current offset, such as *os.File.
+		start, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return
+		}
+		end, err := v.Seek(0, io.SeekEnd)
+		if err != nil {
+			return
+		}
+		if _, err = v.Seek(start, io.SeekStart); err != nil {
+			return
+		}
+		req.ContentLength = end - start
+		// replay the body by an independent reader,
+		// req.Body must not be drained by GetBody.
+		if ra, ok := body.(io.ReaderAt); ok {
+			length := req.ContentLength
+			req.GetBody = func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(io.NewSectionReader(ra, start, length)), nil
+			}
+		}
 	default:
 		// From http.NewRequestWithContext() comment:
 		// This is where we'd set it to -1 (at least
@@ -113,6 +279,7 @@ func setBody(req *http.Request, body io.Reader) {
 		// that broke people during the Go 1.8 testing
 		// period. People depend on it being 0 I
 		// guess. Maybe retry later. See Issue 18117.
+		// WithChunked sets it to -1 explicitly.
 	}
 }
 
@@ -120,7 +287,7 @@ func setBody(req *http.Request, body io.Reader) {
 func WithQuery(params map[string]string) Option {
 	return func(o *Options) {
 		for k, v := range params {
-			o.Values.Set(k, v)
+			o.setQuery(k, v)
 		}
 	}
 }
@@ -134,7 +301,32 @@ func WithQuery(params map[string]string) Option {
 // and the request URL will be "http://localhost/api?name=jack&id=18"
 func WithQueryValue(key, value string) Option {
 	return func(o *Options) {
-		o.Values.Set(key, value)
+		o.setQuery(key, value)
+	}
+}
+
+// WithQueryAdd add the values of key into query,
+// unlike WithQueryValue, it appends instead of replacing.
+// Example:
+//
+// body, err := Get("http://localhost/api",
+//			WithQueryAdd("tag", "a", "b"))
+// and the request URL will be "http://localhost/api?tag=a&tag=b"
+func WithQueryAdd(key string, values ...string) Option {
+	return func(o *Options) {
+		for _, v := range values {
+			o.addQuery(key, v)
+		}
+	}
+}
+
+// WithQueryValues merge v into query,
+// the values of the keys in v replace the existing ones.
+func WithQueryValues(v urlpkg.Values) Option {
+	return func(o *Options) {
+		for k, vs := range v {
+			o.setQuery(k, vs...)
+		}
 	}
 }
 
@@ -145,14 +337,18 @@ func WithPostForm(params map[string]string) Option {
 		for k, v := range params {
 			vals.Set(k, v)
 		}
-
-		o.Request.Method = http.MethodPost
-		o.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-		body := strings.NewReader(vals.Encode())
-		setBody(o.Request, body)
+		setPostForm(o.Request, vals)
+		o.setBodyOption("WithPostForm")
 	}
 }
 
+func setPostForm(req *http.Request, vals urlpkg.Values) {
+	req.Method = http.MethodPost
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	body := strings.NewReader(vals.Encode())
+	setBody(req, body)
+}
+
 // WithPostJSON marshal v to the JSON bytes and set to the request body.
 func WithPostJSON(v interface{}) Option {
 	return func(o *Options) {
@@ -166,6 +362,7 @@ func WithPostJSON(v interface{}) Option {
 		o.Request.Header.Set("Content-Type", "application/json")
 		body := bytes.NewBuffer(data)
 		setBody(o.Request, body)
+		o.setBodyOption("WithPostJSON")
 	}
 }
 
@@ -182,15 +379,19 @@ func WithAddCookie(cookie *http.Cookie) Option {
 func WithRequest(req *http.Request) Option {
 	return func(o *Options) {
 		o.Request = req
+		o.discarded = append(o.discarded, o.bodyOptions...)
+		o.bodyOptions = nil
 	}
 }
 
-// WithCheckStatus treat non-200 as error
+// WithCheckStatus treat non-2xx as error, see WithCheckStatusOK
+// for the exact 200 check.
 // NOTE it only effected which method with bytes return,
 // method with *http.Response return does not effected.
 func WithCheckStatus(check bool) Option {
 	return func(o *Options) {
 		o.checkStatus = check
+		o.expectStatus = nil
 	}
 }
 
@@ -213,6 +414,7 @@ func WithMultipart(params map[string]string) Option {
 		o.Request.Header.Set("Content-Type", writer.FormDataContentType())
 		o.Request.Method = http.MethodPost
 		setBody(o.Request, buf)
+		o.setBodyOption("WithMultipart")
 	}
 }
 
@@ -248,5 +450,6 @@ func WithMultipartFile(fieldname, filename string, data []byte, params ...map[st
 		o.Request.Header.Set("Content-Type", writer.FormDataContentType())
 		o.Request.Method = http.MethodPost
 		setBody(o.Request, buf)
+		o.setBodyOption("WithMultipartFile")
 	}
 }
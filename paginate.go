@@ -0,0 +1,127 @@
+package xreq
+
+import (
+	"net/http"
+	urlpkg "net/url"
+	"strings"
+)
+
+// Pager iterates the pages of a paginated API.
+//
+// Example:
+//
+// p := cli.Paginate("https://api.github.com/repos/golang/go/issues", WithContext(ctx))
+// for p.Next() {
+// 	var issues []Issue
+// 	if err := p.Page().JSON(&issues); err != nil {
+// 		return err
+// 	}
+// }
+// if err := p.Err(); err != nil {
+// 	return err
+// }
+type Pager struct {
+	c    *Client
+	url  string
+	opt  []Option
+	next func(resp *Response) (string, error)
+	page *Response
+	err  error
+}
+
+// Paginate return a Pager of the default client, see Client.Paginate.
+func Paginate(url string, opt ...Option) *Pager {
+	return defaultClient.Paginate(url, opt...)
+}
+
+// Paginate return a Pager starting from url and following the
+// RFC 5988 Link header with rel="next", such as the GitHub API.
+// The options apply to every page and the status is checked as
+// WithCheckStatus(true) unless opt overrides it.
+func (c *Client) Paginate(url string, opt ...Option) *Pager {
+	return c.newPager(url, linkNext, opt)
+}
+
+func (c *Client) newPager(url string, next func(resp *Response) (string, error), opt []Option) *Pager {
+	return &Pager{
+		c:    c,
+		url:  url,
+		opt:  append([]Option{WithCheckStatus(true)}, opt...),
+		next: next,
+	}
+}
+
+// Next fetch the next page and reports whether it succeeded,
+// it return false once the pages are exhausted or any error occurs.
+func (p *Pager) Next() bool {
+	if p.err != nil || p.url == "" {
+		return false
+	}
+	p.page, p.err = p.c.DoR(p.url, p.opt...)
+	if p.err != nil {
+		return false
+	}
+	if _, p.err = p.page.Bytes(); p.err != nil {
+		return false
+	}
+	if p.url, p.err = p.next(p.page); p.err != nil {
+		return false
+	}
+	return true
+}
+
+// Page return the current page, whose body has been read.
+func (p *Pager) Page() *Response {
+	return p.page
+}
+
+// Err return the error stopped the iteration.
+func (p *Pager) Err() error {
+	return p.err
+}
+
+// linkNext return the URL of rel="next" in the Link header of resp,
+// resolved against the request URL.
+func linkNext(resp *Response) (string, error) {
+	for _, v := range resp.Header.Values("Link") {
+		for _, link := range strings.Split(v, ",") {
+			if u, ok := parseLink(link, "next"); ok {
+				return resolveURL(resp.Response, u)
+			}
+		}
+	}
+	return "", nil
+}
+
+// parseLink return the URL of a link-value if it has the relation rel.
+func parseLink(link, rel string) (string, bool) {
+	parts := strings.Split(link, ";")
+	target := strings.TrimSpace(parts[0])
+	if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+		return "", false
+	}
+	for _, param := range parts[1:] {
+		kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+		if len(kv) != 2 || !strings.EqualFold(kv[0], "rel") {
+			continue
+		}
+		for _, r := range strings.Fields(strings.Trim(kv[1], `"`)) {
+			if strings.EqualFold(r, rel) {
+				return target[1 : len(target)-1], true
+			}
+		}
+	}
+	return "", false
+}
+
+// resolveURL resolve ref against the request URL of resp.
+func resolveURL(resp *http.Response, ref string) (string, error) {
+	u, err := urlpkg.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	if resp.Request == nil {
+		return u.String(), nil
+	}
+	return resp.Request.URL.ResolveReference(u).String(), nil
+}
@@ -0,0 +1,157 @@
+package xreq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	urlpkg "net/url"
+	"strconv"
+	"strings"
+)
+
+// Pagination defines the cursor or the page/offset pagination
+// of an API, see Client.PaginateWith.
+type Pagination struct {
+	// CursorParam is the query param of the cursor, which is extracted
+	// from each page by NextCursor or CursorPath, the pages stop at
+	// an empty cursor.
+	CursorParam string
+	// CursorPath is the dot separated path of the next cursor
+	// in the JSON body, e.g. "meta.next_cursor".
+	CursorPath string
+	// NextCursor extracts the next cursor from the page,
+	// it takes precedence over the CursorPath.
+	NextCursor func(page *Response) (string, error)
+
+	// PageParam is the query param of the page number or the offset,
+	// it starts from Start and increases by Step (default 1) per page,
+	// e.g. "page" from 1 or "offset" from 0 by the page size.
+	PageParam string
+	Start     int
+	Step      int
+	// ItemsPath is the dot separated path of the items array in the JSON
+	// body, empty means the body itself, the pages stop at an empty array or null.
+	ItemsPath string
+	// Done reports whether the page is the last one,
+	// it takes precedence over the ItemsPath.
+	Done func(page *Response) (bool, error)
+}
+
+// PaginateWith return a Pager of the default client, see Client.PaginateWith.
+func PaginateWith(url string, p Pagination, opt ...Option) *Pager {
+	return defaultClient.PaginateWith(url, p, opt...)
+}
+
+// PaginateWith return a Pager starting from url with the cursor pagination
+// if p.CursorParam is set, otherwise the page/offset pagination.
+// The options apply to every page and the status is checked as
+// WithCheckStatus(true) unless opt overrides it.
+func (c *Client) PaginateWith(url string, p Pagination, opt ...Option) *Pager {
+	if p.CursorParam != "" {
+		return c.newPager(url, p.nextCursor(url), opt)
+	}
+	if p.Step == 0 {
+		p.Step = 1
+	}
+	start, err := withQueryParam(url, p.PageParam, strconv.Itoa(p.Start))
+	pager := c.newPager(start, p.nextPage(url), opt)
+	pager.err = err
+	return pager
+}
+
+func (p Pagination) nextCursor(base string) func(resp *Response) (string, error) {
+	return func(resp *Response) (string, error) {
+		var cursor string
+		var err error
+		if p.NextCursor != nil {
+			cursor, err = p.NextCursor(resp)
+		} else {
+			cursor, err = jsonPathString(resp, p.CursorPath)
+		}
+		if err != nil || cursor == "" {
+			return "", err
+		}
+		return withQueryParam(base, p.CursorParam, cursor)
+	}
+}
+
+func (p Pagination) nextPage(base string) func(resp *Response) (string, error) {
+	n := p.Start
+	return func(resp *Response) (string, error) {
+		done, err := p.isDone(resp)
+		if err != nil || done {
+			return "", err
+		}
+		n += p.Step
+		return withQueryParam(base, p.PageParam, strconv.Itoa(n))
+	}
+}
+
+// isDone reports whether resp is the last page.
+func (p Pagination) isDone(resp *Response) (bool, error) {
+	if p.Done != nil {
+		return p.Done(resp)
+	}
+	v, err := jsonPath(resp, p.ItemsPath)
+	if err != nil {
+		return false, err
+	}
+	if v == nil {
+		return true, nil
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("pagination items %q is not an array", p.ItemsPath)
+	}
+	return len(items) == 0, nil
+}
+
+// jsonPathString return the value at path of the JSON body as a string,
+// it's empty if the value is null or absent.
+func jsonPathString(resp *Response, path string) (string, error) {
+	v, err := jsonPath(resp, path)
+	if err != nil || v == nil {
+		return "", err
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+// jsonPath return the value at the dot separated path of the JSON body.
+func jsonPath(resp *Response, path string) (interface{}, error) {
+	data, err := resp.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err = dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("json unmarshal error: %w", err)
+	}
+	if path == "" {
+		return v, nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		v = m[key]
+	}
+	return v, nil
+}
+
+// withQueryParam return url with the query param key set to value.
+func withQueryParam(url, key, value string) (string, error) {
+	u, err := urlpkg.Parse(url)
+	if err != nil {
+		return "", fmt.Errorf("parse url error: %w", err)
+	}
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
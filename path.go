@@ -0,0 +1,173 @@
+package xreq
+
+import (
+	"fmt"
+	urlpkg "net/url"
+	"strings"
+)
+
+// WithPathParam substitute the {key} segments in the URL path
+// with the path-escaped value.
+// Example:
+//
+// body, err := Get("http://localhost/users/{id}",
+//			WithPathParam("id", "42"))
+// and the request URL will be "http://localhost/users/42"
+func WithPathParam(key, value string) Option {
+	return func(o *Options) {
+		if o.pathParams == nil {
+			o.pathParams = make(map[string]string)
+		}
+		o.pathParams[key] = value
+	}
+}
+
+// WithPathParams substitute the {key} segments in the URL path
+// with the path-escaped values of params.
+func WithPathParams(params map[string]string) Option {
+	return func(o *Options) {
+		if o.pathParams == nil {
+			o.pathParams = make(map[string]string, len(params))
+		}
+		for k, v := range params {
+			o.pathParams[k] = v
+		}
+	}
+}
+
+// expandPath substitute the path params in u, the path is scanned
+// once from left to right, so a substituted value is never expanded again.
+func expandPath(u *urlpkg.URL, params map[string]string) error {
+	tmpl := u.EscapedPath()
+	var b strings.Builder
+	for i := 0; i < len(tmpl); {
+		name, n := placeholder(tmpl[i:])
+		if v, ok := params[name]; ok && n > 0 {
+			b.WriteString(urlpkg.PathEscape(v))
+			i += n
+			continue
+		}
+		b.WriteByte(tmpl[i])
+		i++
+	}
+	path := b.String()
+
+	unescaped, err := urlpkg.PathUnescape(path)
+	if err != nil {
+		return fmt.Errorf("path unescape error: %w", err)
+	}
+	u.Path = unescaped
+	u.RawPath = path
+	return nil
+}
+
+// placeholder return the name and the length of the {name} placeholder
+// at the start of s, the braces may be escaped as %7B and %7D.
+func placeholder(s string) (string, int) {
+	open, close := "{", "}"
+	if len(s) >= 3 && strings.EqualFold(s[:3], "%7B") {
+		open, close = s[:3], "%7D"
+	} else if !strings.HasPrefix(s, open) {
+		return "", 0
+	}
+	end := strings.Index(strings.ToUpper(s[len(open):]), close)
+	if end < 0 {
+		return "", 0
+	}
+	return s[len(open) : len(open)+end], len(open) + end + len(close)
+}
+
+// WithPathJoin append the path-escaped segments to the URL path,
+// the slashes between segments are normalized and a slash inside
+// a segment is escaped, so it can't change the route, and the
+// dot segments "." and ".." are rejected.
+// Example:
+//
+// body, err := Get("http://localhost/api/",
+//			WithPathJoin("users", "a/b"))
+// and the request URL will be "http://localhost/api/users/a%2Fb"
+func WithPathJoin(segments ...string) Option {
+	return func(o *Options) {
+		if err := joinPath(o.Request.URL, segments); err != nil {
+			o.Err = err
+		}
+	}
+}
+
+func joinPath(u *urlpkg.URL, segments []string) error {
+	path := strings.TrimRight(u.EscapedPath(), "/")
+	for _, s := range segments {
+		switch s {
+		case "":
+			continue
+		case ".", "..":
+			return fmt.Errorf("invalid path segment: %q", s)
+		}
+		path += "/" + urlpkg.PathEscape(s)
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	// path is escaped by ourselves, unescape won't fail.
+	u.Path, _ = urlpkg.PathUnescape(path)
+	u.RawPath = path
+	return nil
+}
+
+// URLBuilder builds a URL with safe path joining.
+//
+// Example:
+//
+// u, err := xreq.URL("http://localhost/api").
+//			Path("users", id).
+//			Query("q", term).
+//			Build()
+type URLBuilder struct {
+	u   *urlpkg.URL
+	q   urlpkg.Values
+	err error
+}
+
+// URL return a URLBuilder based on the base URL.
+func URL(base string) *URLBuilder {
+	u, err := urlpkg.Parse(base)
+	if err != nil {
+		return &URLBuilder{err: fmt.Errorf("parse url error: %w", err)}
+	}
+	return &URLBuilder{u: u, q: u.Query()}
+}
+
+// Path append the path-escaped segments to the URL path,
+// the dot segments "." and ".." are rejected.
+func (b *URLBuilder) Path(segments ...string) *URLBuilder {
+	if b.err == nil {
+		b.err = joinPath(b.u, segments)
+	}
+	return b
+}
+
+// Query add key-value into the URL query.
+func (b *URLBuilder) Query(key, value string) *URLBuilder {
+	if b.err == nil {
+		b.q.Add(key, value)
+	}
+	return b
+}
+
+// Build return the URL, or the error of parsing the base URL.
+func (b *URLBuilder) Build() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+	u := *b.u
+	u.RawQuery = b.q.Encode()
+	return u.String(), nil
+}
+
+// String return the URL, it return an empty string
+// if the base URL is invalid, use Build to check the error.
+func (b *URLBuilder) String() string {
+	s, _ := b.Build()
+	return s
+}
@@ -0,0 +1,63 @@
+package xreq
+
+import (
+	"context"
+	"fmt"
+)
+
+// PipelineStep builds the request of a step from the Response of the
+// previous step, which is nil for the first step and whose body has
+// been read, so it can be decoded by prev.JSON.
+type PipelineStep func(prev *Response) (Request, error)
+
+// Pipeline runs the dependent requests in sequence, such as the
+// auth-then-fetch flows, it aborts on the first failure.
+type Pipeline struct {
+	c     *Client
+	ctx   context.Context
+	steps []PipelineStep
+}
+
+// NewPipeline return a Pipeline of the default client.
+func NewPipeline(ctx context.Context) *Pipeline {
+	return defaultClient.NewPipeline(ctx)
+}
+
+// NewPipeline return a Pipeline whose requests share ctx.
+func (c *Client) NewPipeline(ctx context.Context) *Pipeline {
+	return &Pipeline{c: c, ctx: ctx}
+}
+
+// Then append a step to the pipeline.
+func (p *Pipeline) Then(step PipelineStep) *Pipeline {
+	p.steps = append(p.steps, step)
+	return p
+}
+
+// Run the steps in order and return the Response of the last step.
+// The status of each step is checked as WithCheckStatus(true) unless
+// the step overrides it, a step fails on any error and the later
+// steps are not run.
+func (p *Pipeline) Run() (*Response, error) {
+	var prev *Response
+	for i, step := range p.steps {
+		if err := p.ctx.Err(); err != nil {
+			return nil, fmt.Errorf("pipeline step %d: %w", i, err)
+		}
+		req, err := step(prev)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline step %d: %w", i, err)
+		}
+
+		opt := append([]Option{WithContext(p.ctx), WithCheckStatus(true)}, req.Options...)
+		resp, err := p.c.DoR(req.URL, opt...)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline step %d: %w", i, err)
+		}
+		if _, err = resp.Bytes(); err != nil {
+			return nil, fmt.Errorf("pipeline step %d: %w", i, err)
+		}
+		prev = resp
+	}
+	return prev, nil
+}
@@ -0,0 +1,87 @@
+package xreq
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrHostDenied is returned when the host of a request
+// is denied by the Config.AllowHosts or the Config.DenyHosts.
+var ErrHostDenied = errors.New("host denied")
+
+// hostPolicy restricts the hosts of the requests.
+type hostPolicy struct {
+	allow []hostPattern
+	deny  []hostPattern
+	err   error
+}
+
+// hostPattern is a glob like "*.example.com" or a regexp with the prefix "regexp:",
+// the regexp must match the whole host.
+type hostPattern struct {
+	glob string
+	re   *regexp.Regexp
+}
+
+func newHostPolicy(allow, deny []string) *hostPolicy {
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil
+	}
+	p := &hostPolicy{}
+	p.allow, p.err = compileHostPatterns(allow)
+	if p.err == nil {
+		p.deny, p.err = compileHostPatterns(deny)
+	}
+	return p
+}
+
+func compileHostPatterns(patterns []string) ([]hostPattern, error) {
+	hps := make([]hostPattern, 0, len(patterns))
+	for _, p := range patterns {
+		if expr := strings.TrimPrefix(p, "regexp:"); expr != p {
+			re, err := regexp.Compile("^(?:" + expr + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("host pattern error: %w", err)
+			}
+			hps = append(hps, hostPattern{re: re})
+			continue
+		}
+		hps = append(hps, hostPattern{glob: p})
+	}
+	return hps, nil
+}
+
+func (p hostPattern) match(host string) bool {
+	if p.re != nil {
+		return p.re.MatchString(host)
+	}
+	return matchHost([]string{p.glob}, host)
+}
+
+// check return ErrHostDenied if host is denied or not allowed,
+// the denied hosts take precedence over the allowed ones.
+func (p *hostPolicy) check(host string) error {
+	if p == nil {
+		return nil
+	}
+	if p.err != nil {
+		return p.err
+	}
+	host = strings.ToLower(host)
+	for _, d := range p.deny {
+		if d.match(host) {
+			return fmt.Errorf("%w: %s", ErrHostDenied, host)
+		}
+	}
+	if len(p.allow) == 0 {
+		return nil
+	}
+	for _, a := range p.allow {
+		if a.match(host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrHostDenied, host)
+}
@@ -0,0 +1,40 @@
+package xreq
+
+import (
+	"context"
+	"net/http"
+)
+
+type headersKey struct{}
+
+// ContextWithHeaders return a copy of ctx carrying the headers,
+// the Client merges them into the outbound requests made with the context,
+// so the middleware of an HTTP server can stash tenant or trace headers
+// and have them forwarded automatically.
+// The headers carried by ctx are merged with the existing ones.
+func ContextWithHeaders(ctx context.Context, header http.Header) context.Context {
+	merged := HeadersFromContext(ctx).Clone()
+	if merged == nil {
+		merged = make(http.Header, len(header))
+	}
+	for k, vs := range header {
+		merged[http.CanonicalHeaderKey(k)] = append([]string(nil), vs...)
+	}
+	return context.WithValue(ctx, headersKey{}, merged)
+}
+
+// HeadersFromContext return the headers carried by ctx.
+func HeadersFromContext(ctx context.Context) http.Header {
+	h, _ := ctx.Value(headersKey{}).(http.Header)
+	return h
+}
+
+// mergeContextHeaders set the headers carried by the context of req,
+// the headers set by options take precedence.
+func mergeContextHeaders(req *http.Request) {
+	for k, vs := range HeadersFromContext(req.Context()) {
+		if _, ok := req.Header[k]; !ok {
+			req.Header[k] = append([]string(nil), vs...)
+		}
+	}
+}
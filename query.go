@@ -0,0 +1,193 @@
+package xreq
+
+import (
+	"fmt"
+	urlpkg "net/url"
+	"sort"
+	"strings"
+)
+
+// ArrayStyle defines how the multiple values of a query key are encoded.
+type ArrayStyle int
+
+const (
+	// ArrayRepeat repeats the key for every value: tag=a&tag=b.
+	ArrayRepeat ArrayStyle = iota
+	// ArrayComma joins the values with comma: tag=a,b.
+	ArrayComma
+	// ArrayBrackets appends brackets to the key: tag[]=a&tag[]=b,
+	// which is expected by Rails and PHP.
+	ArrayBrackets
+)
+
+// WithArrayStyle set the encoding style of the keys with multiple values
+// in query, it overrides the Config.ArrayStyle of the Client.
+func WithArrayStyle(style ArrayStyle) Option {
+	return func(o *Options) {
+		o.arrayStyle = style
+	}
+}
+
+// WithQueryDeep set the nested params into query in the deep-object style.
+// Example:
+//
+// body, err := Get("http://localhost/api",
+//			WithQueryDeep(map[string]interface{}{
+//				"filter": map[string]interface{}{
+//					"status":   "open",
+//					"assignee": "me",
+//				},
+//			}))
+// and the request URL will be
+// "http://localhost/api?filter[assignee]=me&filter[status]=open" (escaped)
+func WithQueryDeep(params map[string]interface{}) Option {
+	return func(o *Options) {
+		for k, v := range params {
+			setDeepValue(o, k, v)
+		}
+	}
+}
+
+// setDeepValue set v into vals with key, the maps are expanded into
+// key[name] recursively, the slices of scalars are added under the same key
+// and the slices of maps are expanded into key[index].
+func setDeepValue(o *Options, key string, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, vv := range v {
+			setDeepValue(o, key+"["+k+"]", vv)
+		}
+	case map[string]string:
+		for k, vv := range v {
+			o.setQuery(key+"["+k+"]", vv)
+		}
+	case []string:
+		o.setQuery(key, v...)
+	case []interface{}:
+		o.Values.Del(key)
+		for i, vv := range v {
+			switch vv.(type) {
+			case map[string]interface{}, map[string]string:
+				setDeepValue(o, fmt.Sprintf("%s[%d]", key, i), vv)
+			default:
+				o.addQuery(key, fmt.Sprint(vv))
+			}
+		}
+	case nil:
+		o.setQuery(key, "")
+	default:
+		o.setQuery(key, fmt.Sprint(v))
+	}
+}
+
+// WithKeepQueryOrder keep the query keys in the order they were added,
+// the keys of the URL come first and then the keys set by the options,
+// instead of sorting them by key.
+// It's required by some signature schemes.
+// NOTE WithQuery and WithQueryValues take a map,
+// so the order of their keys is still random.
+func WithKeepQueryOrder(keep bool) Option {
+	return func(o *Options) {
+		o.keepOrder = keep
+	}
+}
+
+// setQuery replace the values of key and record its order.
+func (o *Options) setQuery(key string, values ...string) {
+	o.Values[key] = append([]string(nil), values...)
+	o.addQueryKey(key)
+}
+
+// addQuery append value to key and record its order.
+func (o *Options) addQuery(key, value string) {
+	o.Values.Add(key, value)
+	o.addQueryKey(key)
+}
+
+func (o *Options) addQueryKey(key string) {
+	for _, k := range o.queryKeys {
+		if k == key {
+			return
+		}
+	}
+	o.queryKeys = append(o.queryKeys, key)
+}
+
+// parseQueryKeys return the keys of the raw query in order.
+func parseQueryKeys(query string) []string {
+	var keys []string
+	for _, kv := range strings.Split(query, "&") {
+		if kv == "" {
+			continue
+		}
+		k := strings.SplitN(kv, "=", 2)[0]
+		if k, err := urlpkg.QueryUnescape(k); err == nil {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// encodeQuery encodes v like url.Values.Encode,
+// the keys in order come first and the rest are sorted by key,
+// the keys with multiple values are encoded in the style.
+func encodeQuery(v urlpkg.Values, style ArrayStyle, order []string) string {
+	if style == ArrayRepeat && len(order) == 0 {
+		return v.Encode()
+	}
+
+	var buf strings.Builder
+	for _, k := range queryKeys(v, order) {
+		writeQueryKey(&buf, k, v[k], style)
+	}
+	return buf.String()
+}
+
+func queryKeys(v urlpkg.Values, order []string) []string {
+	keys := make([]string, 0, len(v))
+	seen := make(map[string]bool, len(v))
+	for _, k := range order {
+		if _, ok := v[k]; ok && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	n := len(keys)
+	for k := range v {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys[n:])
+	return keys
+}
+
+func writeQueryKey(buf *strings.Builder, key string, vs []string, style ArrayStyle) {
+	key = urlpkg.QueryEscape(key)
+	if len(vs) > 1 && style == ArrayComma {
+		if buf.Len() > 0 {
+			buf.WriteByte('&')
+		}
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		for i, v := range vs {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(urlpkg.QueryEscape(v))
+		}
+		return
+	}
+
+	if len(vs) > 1 && style == ArrayBrackets {
+		key += "[]"
+	}
+	for _, v := range vs {
+		if buf.Len() > 0 {
+			buf.WriteByte('&')
+		}
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(urlpkg.QueryEscape(v))
+	}
+}
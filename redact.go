@@ -0,0 +1,163 @@
+package xreq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	urlpkg "net/url"
+	"strconv"
+	"strings"
+)
+
+// redacted replaces the secrets in the URLs of the errors.
+const redacted = "xxxxx"
+
+// redactURL return u without the userinfo
+// and with the values of the query params masked.
+func redactURL(u *urlpkg.URL, params []string) string {
+	if u == nil {
+		return ""
+	}
+	ru := *u
+	ru.User = nil
+	if len(params) > 0 && ru.RawQuery != "" {
+		q := ru.Query()
+		masked := false
+		for key, vals := range q {
+			for _, p := range params {
+				if strings.EqualFold(key, p) {
+					for i := range vals {
+						vals[i] = redacted
+					}
+					masked = true
+				}
+			}
+		}
+		if masked {
+			ru.RawQuery = q.Encode()
+		}
+	}
+	return ru.String()
+}
+
+// urlErrorOp return the Op of url.Error for method like net/http.
+func urlErrorOp(method string) string {
+	if method == "" {
+		return "Get"
+	}
+	return method[:1] + strings.ToLower(method[1:])
+}
+
+// requestError wraps err with the method and the redacted URL of req,
+// the URL of a *url.Error returned by net/http is redacted in place.
+func requestError(req *http.Request, params []string, err error) error {
+	var uerr *urlpkg.Error
+	if errors.As(err, &uerr) {
+		if u, perr := urlpkg.Parse(uerr.URL); perr == nil {
+			uerr.URL = redactURL(u, params)
+		}
+		return err
+	}
+	return &urlpkg.Error{Op: urlErrorOp(req.Method), URL: redactURL(req.URL, params), Err: err}
+}
+
+// Redaction defines the sensitive data masked in the errors, the logs
+// of NewLoggingTransport and the dumps of the debug mode.
+type Redaction struct {
+	// Headers are the headers masked along with the Authorization,
+	// Proxy-Authorization, Cookie and Set-Cookie in the logs and the dumps.
+	Headers []string
+	// Query are the query params masked, the userinfo of the URLs
+	// is always stripped.
+	Query []string
+	// JSONFields are the fields masked in the JSON bodies, the path
+	// is separated by dots from the root such as "user.password" where
+	// "*" matches any field or element, and a name without dot such as
+	// "token" matches the field at any depth.
+	JSONFields []string
+}
+
+// redactJSON return data with the fields masked,
+// data is returned as is if it's not JSON.
+func redactJSON(data []byte, fields []string) []byte {
+	if len(fields) == 0 || len(data) == 0 {
+		return data
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return data
+	}
+	masked := false
+	for _, f := range fields {
+		path := strings.Split(f, ".")
+		if len(path) == 1 {
+			masked = redactJSONAny(v, f) || masked
+		} else {
+			masked = redactJSONPath(v, path) || masked
+		}
+	}
+	if !masked {
+		return data
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// redactJSONPath mask the value of path in v.
+func redactJSONPath(v interface{}, path []string) bool {
+	masked := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if path[0] != "*" && path[0] != k {
+				continue
+			}
+			if len(path) == 1 {
+				v[k] = redacted
+				masked = true
+			} else {
+				masked = redactJSONPath(child, path[1:]) || masked
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if path[0] != "*" && path[0] != strconv.Itoa(i) {
+				continue
+			}
+			if len(path) == 1 {
+				v[i] = redacted
+				masked = true
+			} else {
+				masked = redactJSONPath(child, path[1:]) || masked
+			}
+		}
+	}
+	return masked
+}
+
+// redactJSONAny mask the values of the field name at any depth in v.
+func redactJSONAny(v interface{}, name string) bool {
+	masked := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if k == name {
+				v[k] = redacted
+				masked = true
+				continue
+			}
+			masked = redactJSONAny(child, name) || masked
+		}
+	case []interface{}:
+		for _, child := range v {
+			masked = redactJSONAny(child, name) || masked
+		}
+	}
+	return masked
+}
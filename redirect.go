@@ -0,0 +1,135 @@
+package xreq
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// defaultMaxRedirects is the max redirects followed by default, as net/http.
+const defaultMaxRedirects = 10
+
+// WithMaxRedirects set the max redirects to follow, the request fails
+// if it's redirected more than n times, it overrides the Config.MaxRedirects.
+func WithMaxRedirects(n int) Option {
+	return func(o *Options) {
+		o.maxRedirects = n
+	}
+}
+
+// WithNoRedirect don't follow the redirects, the 3xx response is returned
+// with the Location header intact, it's useful for short-link resolution
+// and OAuth flows where the redirect must be inspected.
+func WithNoRedirect() Option {
+	return func(o *Options) {
+		o.noRedirect = true
+	}
+}
+
+// WithRedirectHook add a hook invoked before following each redirect,
+// req is the upcoming request and via is the requests made already,
+// the oldest first. The hook can modify the headers of req,
+// and return an error to stop the redirects, see http.Client.CheckRedirect.
+func WithRedirectHook(hook func(req *http.Request, via []*http.Request) error) Option {
+	return func(o *Options) {
+		o.redirectHooks = append(o.redirectHooks, hook)
+	}
+}
+
+// WithRedirectHeaders keep the headers of the original request on the
+// redirects to the hosts, net/http strips the sensitive headers such as
+// Authorization and Cookie on the redirects to a different domain.
+// The hosts are matched by path.Match, e.g. "*.example.com".
+func WithRedirectHeaders(hosts []string, headers ...string) Option {
+	return WithRedirectHook(func(req *http.Request, via []*http.Request) error {
+		if !matchHost(hosts, req.URL.Hostname()) {
+			return nil
+		}
+		for _, h := range headers {
+			if vs := via[0].Header.Values(h); len(vs) > 0 {
+				req.Header[http.CanonicalHeaderKey(h)] = vs
+			}
+		}
+		return nil
+	})
+}
+
+// matchHost reports whether host matches any of the patterns.
+func matchHost(patterns []string, host string) bool {
+	host = strings.ToLower(host)
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RedirectHop is a request made while following the redirects.
+type RedirectHop struct {
+	// URL is the requested URL.
+	URL string
+	// StatusCode is the status code of the response.
+	StatusCode int
+	// Duration is the time from sending the request
+	// to receiving the response headers.
+	Duration time.Duration
+}
+
+// WithRedirectTrace records the requests made while following the
+// redirects into hops, the last one is the final response,
+// so a request without redirects records a single hop.
+func WithRedirectTrace(hops *[]RedirectHop) Option {
+	return func(o *Options) {
+		o.redirectTrace = &redirectTrace{hops: hops}
+	}
+}
+
+// redirectTrace records the redirect hops of a request.
+type redirectTrace struct {
+	hops  *[]RedirectHop
+	start time.Time
+}
+
+func (t *redirectTrace) begin() {
+	*t.hops = (*t.hops)[:0]
+	t.start = time.Now()
+}
+
+func (t *redirectTrace) record(url string, code int) {
+	now := time.Now()
+	*t.hops = append(*t.hops, RedirectHop{URL: url, StatusCode: code, Duration: now.Sub(t.start)})
+	t.start = now
+}
+
+// checkRedirect return the CheckRedirect of http.Client for opts.
+func checkRedirect(opts *Options) func(req *http.Request, via []*http.Request) error {
+	max := opts.maxRedirects
+	if max < 0 {
+		max = 0
+	}
+	noRedirect, hooks, trace := opts.noRedirect, opts.redirectHooks, opts.redirectTrace
+	result := opts.result
+	return func(req *http.Request, via []*http.Request) error {
+		if noRedirect {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > max {
+			return fmt.Errorf("stopped after %d redirects", max)
+		}
+		for _, hook := range hooks {
+			if err := hook(req, via); err != nil {
+				return err
+			}
+		}
+		if trace != nil && req.Response != nil {
+			trace.record(via[len(via)-1].URL.String(), req.Response.StatusCode)
+		}
+		if result != nil && req.Response != nil {
+			result.captureCookies(req.Response)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+package xreq
+
+import (
+	"context"
+	"net/http"
+)
+
+type requestIDKey struct{}
+
+// ContextWithRequestID return a copy of ctx carrying the request ID,
+// the Client with Config.RequestID forwards it to the outbound requests.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext return the request ID carried by ctx.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// setRequestID set the request ID header into req and return the ID,
+// the ID is taken from the header set by options, the context,
+// or generated.
+func (c *Client) setRequestID(req *http.Request) string {
+	header := headerOr(c.config.RequestIDHeader, "X-Request-ID")
+	if id := req.Header.Get(header); id != "" {
+		return id
+	}
+
+	id := RequestIDFromContext(req.Context())
+	if id == "" {
+		// newUUID only fails if the system random source fails,
+		// it's not worth failing the request.
+		id, _ = newUUID()
+	}
+	req.Header.Set(header, id)
+	return id
+}
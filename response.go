@@ -0,0 +1,179 @@
+package xreq
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Response wraps the *http.Response with the helpers to consume the body,
+// the body is closed once it's consumed by any of them, so the caller
+// only needs to Close the Response if the body is not consumed.
+// The trailers and the status are checked as the DoBytes when the body
+// is consumed, see WithCheckStatus and WithExpectTrailers.
+type Response struct {
+	*http.Response
+
+	opts    *Options
+	sniffed string
+	once    sync.Once
+	data    []byte
+	err     error
+}
+
+// errBodyConsumed is returned when the body has been
+// consumed by the SaveTo or the Close.
+var errBodyConsumed = errors.New("response body consumed")
+
+// DoR method construct a HTTP request with options and return the Response.
+func DoR(url string, opt ...Option) (*Response, error) {
+	return defaultClient.DoR(url, opt...)
+}
+
+// DoR method construct a HTTP request with options and return the Response.
+func (c *Client) DoR(url string, opt ...Option) (*Response, error) {
+	opts := &Options{result: &Result{}}
+	resp, err := c.do(opts, url, opt...)
+	if err != nil {
+		return nil, err
+	}
+	r := &Response{Response: resp, opts: opts}
+	if opts.sniff {
+		r.sniffed = sniffBody(resp)
+	}
+	return r, nil
+}
+
+// Result return the metadata of the request,
+// which is the one given by WithResult if any.
+func (r *Response) Result() *Result {
+	return r.opts.result
+}
+
+// ContentType return the Content-Type of the response, which is
+// the sniffed one if the header is missing or generic
+// and WithSniffContentType is set.
+func (r *Response) ContentType() string {
+	if r.sniffed != "" {
+		return r.sniffed
+	}
+	return r.Header.Get("Content-Type")
+}
+
+// Bytes read the body and return it, the result is kept
+// so it can be called multiple times.
+func (r *Response) Bytes() ([]byte, error) {
+	r.once.Do(func() {
+		r.data, r.err = readBody(r.opts, r.Response)
+	})
+	return r.data, r.err
+}
+
+// String return the body as a string.
+func (r *Response) String() (string, error) {
+	data, err := r.Bytes()
+	return string(data), err
+}
+
+// JSON unmarshal the body into v.
+func (r *Response) JSON(v interface{}) error {
+	data, err := r.Bytes()
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("json unmarshal error: %w", err)
+	}
+	return nil
+}
+
+// XML unmarshal the body into v.
+func (r *Response) XML(v interface{}) error {
+	data, err := r.Bytes()
+	if err != nil {
+		return err
+	}
+	if err = xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("xml unmarshal error: %w", err)
+	}
+	return nil
+}
+
+// SaveTo write the body into the file of path, the body is streamed
+// into the file unless it has been read, the file is not created
+// if the status check fails.
+func (r *Response) SaveTo(path string) error {
+	if checkStatus(r.opts, r.Response, nil) != nil {
+		_, err := r.Bytes()
+		return err
+	}
+
+	saved := false
+	r.once.Do(func() {
+		saved = true
+		r.err = r.save(path)
+		if r.err == nil {
+			r.err = errBodyConsumed
+		}
+	})
+	if saved {
+		if r.err == errBodyConsumed {
+			return nil
+		}
+		return r.err
+	}
+
+	data, err := r.Bytes()
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("write file error: %w", err)
+	}
+	return nil
+}
+
+// save stream the body into the file of path.
+func (r *Response) save(path string) error {
+	defer r.Body.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create file error: %w", err)
+	}
+	_, err = io.Copy(f, r.Body)
+	if cerr := f.Close(); err == nil && cerr != nil {
+		return fmt.Errorf("close file error: %w", cerr)
+	}
+	if err != nil {
+		err = fmt.Errorf("read body error: %w", err)
+		return requestError(r.Request, r.opts.redactQuery, err)
+	}
+	if err = checkTrailers(r.Response, r.opts.trailers); err != nil {
+		return requestError(r.Request, r.opts.redactQuery, err)
+	}
+	return nil
+}
+
+// IsSuccess reports whether the status code is 2xx.
+func (r *Response) IsSuccess() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Close discard the rest of the body and close it,
+// it's a no-op if the body has been consumed.
+func (r *Response) Close() error {
+	var err error
+	r.once.Do(func() {
+		io.Copy(ioutil.Discard, r.Body)
+		err = r.Body.Close()
+		r.err = errBodyConsumed
+	})
+	return err
+}
@@ -0,0 +1,127 @@
+package xreq
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// Result holds the metadata of a finished request.
+type Result struct {
+	// ContentEncoding is the original Content-Encoding of the response
+	// which has been decoded transparently, empty if it wasn't encoded.
+	ContentEncoding string
+	// RequestID is the injected request ID if Config.RequestID is enabled.
+	RequestID string
+	// TraceID and SpanID are the IDs of the traceparent
+	// if the trace context is enabled, see WithTraceContext.
+	TraceID string
+	SpanID  string
+	// Route is the route template given by WithRoute.
+	Route string
+
+	// Duration is the time from sending the request to receiving
+	// the response headers, including the retries and the redirects.
+	Duration time.Duration
+	// Attempts is the number of the attempts, see WithRetry.
+	Attempts int
+	// BytesSent is the bytes of the request body sent by the last attempt.
+	BytesSent int64
+	// ConnReused reports whether the last attempt reused a connection.
+	ConnReused bool
+	// Proto is the protocol of the response, such as "HTTP/2.0".
+	Proto string
+	// Host is the host the request was sent to, see WithFallbackHosts.
+	Host string
+	// Canary reports whether the request was routed to the Config.Canary.
+	Canary bool
+	// CapturedCookies are the cookies set by the response
+	// and the redirects, in the order they were received.
+	CapturedCookies []*http.Cookie
+
+	resp     *http.Response
+	sent     *countingBody
+	received *countingBody
+	// reused is set by the transport for the last attempt,
+	// it's copied into the ConnReused by finish.
+	reused *int32
+}
+
+// Trailer return the trailers received after the response body,
+// it's only complete once the body has been read to EOF.
+func (r *Result) Trailer() http.Header {
+	if r.resp == nil {
+		return nil
+	}
+	return r.resp.Trailer
+}
+
+// BytesReceived return the bytes of the response body received,
+// before the transparent decoding, it's only complete once the body
+// has been read to EOF.
+func (r *Result) BytesReceived() int64 {
+	if r.received == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&r.received.n)
+}
+
+// WithResult fills r with the metadata of the request
+// once the response is received.
+func WithResult(r *Result) Option {
+	return func(o *Options) {
+		o.result = r
+	}
+}
+
+// track instruments the request of an attempt to collect the metadata.
+func (r *Result) track(req *http.Request, attempt int) *http.Request {
+	r.Attempts = attempt
+	reused := new(int32)
+	r.reused = reused
+	ctx := httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			var v int32
+			if info.Reused {
+				v = 1
+			}
+			atomic.StoreInt32(reused, v)
+		},
+	})
+	req = req.WithContext(ctx)
+	if req.Body != nil && req.Body != http.NoBody {
+		r.sent = &countingBody{ReadCloser: req.Body}
+		req.Body = r.sent
+	}
+	return req
+}
+
+// finish fills the metadata of resp received since start.
+func (r *Result) finish(resp *http.Response, start time.Time) {
+	r.Duration = time.Since(start)
+	r.Proto = resp.Proto
+	if r.reused != nil {
+		r.ConnReused = atomic.LoadInt32(r.reused) == 1
+	}
+	r.captureCookies(resp)
+	if r.sent != nil {
+		r.BytesSent = atomic.LoadInt64(&r.sent.n)
+	}
+	r.received = &countingBody{ReadCloser: resp.Body}
+	resp.Body = r.received
+	r.resp = resp
+}
+
+// countingBody counts the bytes read from the body.
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(&b.n, int64(n))
+	return n, err
+}
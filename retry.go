@@ -0,0 +1,295 @@
+package xreq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy defines the retries of the failed requests.
+type RetryPolicy struct {
+	// MaxAttempts is the max attempts including the first one,
+	// the request is not retried if it's less than 2.
+	MaxAttempts int
+	// Backoff is the wait before the first retry,
+	// it's doubled after each retry.
+	Backoff time.Duration
+	// Jitter randomizes the backoff, default NoJitter.
+	Jitter Jitter
+	// MaxInterval caps the wait between the retries, zero means no cap.
+	MaxInterval time.Duration
+	// MaxElapsedTime stops the retries once the next one would start
+	// after it since the first attempt, the last response is returned.
+	// Unlike the Timeout, it never cuts off an attempt.
+	MaxElapsedTime time.Duration
+	// AttemptTimeout cuts off a single attempt, which is retried then,
+	// zero means no limit other than the Timeout.
+	AttemptTimeout time.Duration
+	// Timeout is the overall deadline of all attempts and backoffs,
+	// the deadline of the request context is respected as well.
+	Timeout time.Duration
+	// RetryIf reports whether to retry after an attempt,
+	// default retry on the errors and the 5xx responses,
+	// or the RetryOnStatus if any.
+	RetryIf func(resp *http.Response, err error) bool
+	// RetryOnStatus are the status codes to retry by the default
+	// RetryIf instead of the 5xx, see WithRetryOnStatus.
+	RetryOnStatus []int
+	// AbortOn are the errors that stop the retries immediately,
+	// matched by errors.Is, see WithRetryAbortOn.
+	AbortOn []error
+}
+
+// Jitter defines how the backoff of the retries is randomized,
+// so the clients failed together don't retry in lockstep.
+type Jitter int
+
+const (
+	// NoJitter waits the exponential backoff exactly.
+	NoJitter Jitter = iota
+	// FullJitter waits a random duration in [0, backoff).
+	FullJitter
+	// EqualJitter waits half the backoff plus
+	// a random duration in [0, backoff/2).
+	EqualJitter
+	// DecorrelatedJitter waits a random duration in
+	// [Backoff, 3*previous wait), regardless of the attempts.
+	DecorrelatedJitter
+)
+
+// wait return the wait before the next retry by the exponential
+// backoff and the previous wait.
+func (j Jitter) wait(base, backoff, prev time.Duration) time.Duration {
+	switch j {
+	case FullJitter:
+		return randDuration(backoff)
+	case EqualJitter:
+		return backoff/2 + randDuration(backoff/2)
+	case DecorrelatedJitter:
+		if prev < base {
+			prev = base
+		}
+		return base + randDuration(prev*3-base)
+	}
+	return backoff
+}
+
+// randDuration return a random duration in [0, d).
+func randDuration(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+// WithRetry set the retry policy of the request,
+// it replaces the Config.Retry and the previous retry options.
+// NOTE the request body is replayed via GetBody, a request whose body
+// can't be replayed is not retried.
+func WithRetry(p RetryPolicy) Option {
+	return func(o *Options) {
+		o.retry = p
+	}
+}
+
+// WithAttemptTimeout set the timeout of each attempt of the retries,
+// a slow attempt is cut off and retried while all attempts
+// still respect the RetryPolicy.Timeout and the context deadline.
+func WithAttemptTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.retry.AttemptTimeout = d
+	}
+}
+
+// WithRetryAbortOn stop the retries once an attempt fails with any of
+// errs, e.g. context.DeadlineExceeded not to retry the attempts cut off
+// by the AttemptTimeout. The retries always stop once the context
+// of the request is done, including the backoff between the attempts.
+func WithRetryAbortOn(errs ...error) Option {
+	return func(o *Options) {
+		o.retry.AbortOn = append(append([]error(nil), o.retry.AbortOn...), errs...)
+	}
+}
+
+// abort reports whether err stops the retries of p.
+func (p RetryPolicy) abort(err error) bool {
+	for _, target := range p.AbortOn {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithRetryOnStatus retry the responses of the status codes instead of
+// the 5xx, along with the errors, such as 429, 502, 503 and 504,
+// it takes effect unless the RetryPolicy.RetryIf is set.
+func WithRetryOnStatus(codes ...int) Option {
+	return func(o *Options) {
+		o.retry.RetryOnStatus = codes
+	}
+}
+
+func defaultRetryIf(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// retryOnStatus return a RetryIf retrying the errors and the codes.
+func retryOnStatus(codes []int) func(resp *http.Response, err error) bool {
+	return func(resp *http.Response, err error) bool {
+		if err != nil {
+			return true
+		}
+		for _, code := range codes {
+			if resp.StatusCode == code {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// sendRetry send the request of opts with the retry policy.
+func (c *Client) sendRetry(hc *http.Client, opts *Options) (*http.Response, error) {
+	p := opts.retry
+	if p.MaxAttempts < 2 && p.AttemptTimeout <= 0 && p.Timeout <= 0 {
+		req := opts.Request
+		if opts.result != nil {
+			req = opts.result.track(req, 1)
+		}
+		return c.send(hc, req, opts.authProvider)
+	}
+	retryIf := p.RetryIf
+	if retryIf == nil && len(p.RetryOnStatus) > 0 {
+		retryIf = retryOnStatus(p.RetryOnStatus)
+	} else if retryIf == nil {
+		retryIf = defaultRetryIf
+	}
+	req := opts.Request
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if p.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+	}
+	start := time.Now()
+	backoff, wait := p.Backoff, time.Duration(0)
+	for attempt := 1; ; attempt++ {
+		actx, acancel := ctx, context.CancelFunc(func() {})
+		if p.AttemptTimeout > 0 {
+			actx, acancel = context.WithTimeout(ctx, p.AttemptTimeout)
+		}
+		areq := req.WithContext(actx)
+		if attempt > 1 {
+			// a retry is signed again with its own headers.
+			areq = req.Clone(actx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					acancel()
+					cancel()
+					return nil, fmt.Errorf("get body error: %w", err)
+				}
+				areq.Body = body
+			}
+			if err := opts.sign(areq); err != nil {
+				acancel()
+				cancel()
+				return nil, err
+			}
+		}
+		if opts.result != nil {
+			areq = opts.result.track(areq, attempt)
+		}
+
+		resp, err := c.send(hc, areq, opts.authProvider)
+		retry := attempt < p.MaxAttempts && replayable && ctx.Err() == nil &&
+			(err == nil || !p.abort(err)) && retryIf(resp, err)
+		if retry {
+			wait = p.wait(backoff, wait)
+			retry = p.MaxElapsedTime <= 0 || time.Since(start)+wait <= p.MaxElapsedTime
+		}
+		if !retry {
+			if err != nil {
+				acancel()
+				cancel()
+				return nil, err
+			}
+			// the contexts must live until the body is closed.
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: func() {
+				acancel()
+				cancel()
+			}}
+			return resp, nil
+		}
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		acancel()
+
+		if err = sleepContext(ctx, wait); err != nil {
+			cancel()
+			return nil, fmt.Errorf("retry backoff error: %w", err)
+		}
+		// saturate instead of overflowing to a negative backoff.
+		if backoff > maxBackoff/2 {
+			backoff = maxBackoff
+		} else {
+			backoff *= 2
+		}
+		if p.MaxInterval > 0 && backoff > p.MaxInterval {
+			backoff = p.MaxInterval
+		}
+	}
+}
+
+// maxBackoff bounds the backoff and the wait without the MaxInterval,
+// so the doubling and the DecorrelatedJitter never overflow.
+const maxBackoff = time.Duration(math.MaxInt64 / 3)
+
+// wait return the wait before the next retry
+// by the exponential backoff and the previous wait.
+func (p RetryPolicy) wait(backoff, prev time.Duration) time.Duration {
+	wait := p.Jitter.wait(p.Backoff, backoff, prev)
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	if p.MaxInterval > 0 && wait > p.MaxInterval {
+		wait = p.MaxInterval
+	}
+	return wait
+}
+
+// sleepContext wait d unless ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// cancelBody invokes cancel once the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel func()
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
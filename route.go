@@ -0,0 +1,30 @@
+package xreq
+
+import (
+	"context"
+	"net/http"
+)
+
+type routeKey struct{}
+
+// WithRoute tag the request with the route template, such as
+// "GET /users/{id}", so the metrics and the logs aggregate the requests
+// by the logical route instead of the raw URLs. The route is carried
+// by the request context, see RouteFromContext, and the Result.
+func WithRoute(route string) Option {
+	return func(o *Options) {
+		o.route = route
+	}
+}
+
+// RouteFromContext return the route template of the request
+// given by WithRoute, e.g. in a http.RoundTripper.
+func RouteFromContext(ctx context.Context) string {
+	route, _ := ctx.Value(routeKey{}).(string)
+	return route
+}
+
+// setRoute carry route by the context of req.
+func setRoute(req *http.Request, route string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), routeKey{}, route))
+}
@@ -0,0 +1,145 @@
+package xreq
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// WithSigner set the signer of the request, it's invoked after all options
+// are applied, right before the request is sent, so it sees the final
+// URL, headers and body. It's invoked again for each retry, so the
+// timestamps and the nonces are fresh.
+func WithSigner(sign func(req *http.Request) error) Option {
+	return func(o *Options) {
+		o.signers = append(o.signers, sign)
+	}
+}
+
+// HMACSigner signs the request with HMAC-SHA256, the string to sign is
+//
+//	Method + "\n" + RequestURI + "\n" + Timestamp + "\n" + hex(SHA256(Body))
+//
+// followed by "\n" + Nonce if the request has the NonceHeader set by
+// WithNonce, and the hex signature is set into the SignatureHeader.
+//
+// Example:
+//
+// signer := &xreq.HMACSigner{Key: []byte("secret")}
+// data, _, err := xreq.DoBytes(url,
+//     xreq.WithPostJSON(v),
+//     xreq.WithSigner(signer.Sign),
+// )
+type HMACSigner struct {
+	Key []byte
+	// SignatureHeader is the header of the signature, default "X-Signature".
+	SignatureHeader string
+	// TimestampHeader is the header of the unix timestamp, default "X-Timestamp".
+	TimestampHeader string
+	// NonceHeader is the header of the nonce, default "X-Nonce".
+	NonceHeader string
+	// Now return the current time, default time.Now.
+	Now func() time.Time
+}
+
+// Sign set the timestamp and the signature headers into req.
+func (s *HMACSigner) Sign(req *http.Request) error {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	ts := strconv.FormatInt(now().Unix(), 10)
+
+	bodyHash, err := hashBody(req)
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, s.Key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", req.Method, req.URL.RequestURI(), ts, bodyHash)
+	if nonce := req.Header.Get(headerOr(s.NonceHeader, "X-Nonce")); nonce != "" {
+		fmt.Fprintf(mac, "\n%s", nonce)
+	}
+	req.Header.Set(headerOr(s.TimestampHeader, "X-Timestamp"), ts)
+	req.Header.Set(headerOr(s.SignatureHeader, "X-Signature"), hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
+
+// hashBody return the hex SHA256 of the request body, the body is read
+// from GetBody if possible, otherwise it's buffered and reset into req.
+func hashBody(req *http.Request) (string, error) {
+	h := sha256.New()
+	if req.Body == nil || req.Body == http.NoBody {
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", fmt.Errorf("get body error: %w", err)
+		}
+		defer body.Close()
+		if _, err = io.Copy(h, body); err != nil {
+			return "", fmt.Errorf("read body error: %w", err)
+		}
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	data, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("read body error: %w", err)
+	}
+	setBody(req, bytes.NewBuffer(data))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sign runs the signers on req, it's done for each attempt.
+func (o *Options) sign(req *http.Request) error {
+	for _, sign := range o.signers {
+		if err := sign(req); err != nil {
+			return fmt.Errorf("sign error: %w", err)
+		}
+	}
+	return nil
+}
+
+func headerOr(header, def string) string {
+	if header == "" {
+		return def
+	}
+	return header
+}
+
+// WithNonce set a random nonce into the "X-Nonce" header and the unix
+// timestamp into the "X-Timestamp" header, which are required by the
+// APIs preventing the replays, see WithNonceHeaders.
+func WithNonce() Option {
+	return WithNonceHeaders("X-Nonce", "X-Timestamp")
+}
+
+// WithNonceHeaders set a random nonce into the nonceHeader and the unix
+// timestamp into the timestampHeader, the timestamp is omitted if its
+// header is empty. They are set before the signers so they can be signed,
+// the HMACSigner signs the nonce of its NonceHeader. Each retry gets
+// a fresh nonce.
+func WithNonceHeaders(nonceHeader, timestampHeader string) Option {
+	return func(o *Options) {
+		nonce := func(req *http.Request) error {
+			req.Header.Set(nonceHeader, randomHex(16))
+			if timestampHeader != "" {
+				req.Header.Set(timestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+			}
+			return nil
+		}
+		o.signers = append([]func(req *http.Request) error{nonce}, o.signers...)
+	}
+}
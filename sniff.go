@@ -0,0 +1,60 @@
+package xreq
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// sniffLen is the number of bytes considered by http.DetectContentType.
+const sniffLen = 512
+
+// WithSniffContentType sniff the content type of the response body
+// by http.DetectContentType if the Content-Type is missing or generic,
+// such as "application/octet-stream", see Response.ContentType.
+// NOTE it only effected the method with *Response return.
+func WithSniffContentType() Option {
+	return func(o *Options) {
+		o.sniff = true
+	}
+}
+
+// genericType reports whether the media type ct says nothing
+// about the content.
+func genericType(ct string) bool {
+	if ct == "" {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return true
+	}
+	switch mt {
+	case "application/octet-stream", "application/binary",
+		"application/unknown", "binary/octet-stream":
+		return true
+	}
+	return false
+}
+
+// sniffBody return the sniffed content type of the body of resp
+// if its Content-Type is generic, the peeked bytes are kept in the body.
+func sniffBody(resp *http.Response) string {
+	if !genericType(resp.Header.Get("Content-Type")) {
+		return ""
+	}
+	br := bufio.NewReaderSize(resp.Body, sniffLen)
+	resp.Body = &sniffedBody{Reader: br, Closer: resp.Body}
+	// the error is kept by br and returned by the next Read.
+	data, _ := br.Peek(sniffLen)
+	if len(data) == 0 {
+		return ""
+	}
+	return http.DetectContentType(data)
+}
+
+type sniffedBody struct {
+	*bufio.Reader
+	io.Closer
+}
@@ -0,0 +1,130 @@
+package xreq
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// WithCheckStatusOK treat non-200 as error, which is stricter than
+// WithCheckStatus that accepts the whole 2xx class.
+// NOTE it only effected which method with bytes return.
+func WithCheckStatusOK(check bool) Option {
+	return func(o *Options) {
+		o.checkStatus = check
+		o.expectStatus = nil
+		if check {
+			o.expectStatus = []int{http.StatusOK}
+		}
+	}
+}
+
+// WithExpectStatus treat the statuses not in codes as error,
+// e.g. WithExpectStatus(200, 404) when 404 means not found
+// rather than a failure.
+// NOTE it only effected which method with bytes return.
+func WithExpectStatus(codes ...int) Option {
+	return func(o *Options) {
+		o.checkStatus = true
+		o.expectStatus = codes
+	}
+}
+
+// WithErrorDecoder enable the status check and decode the body of the
+// unexpected statuses into the returned error, the StatusError is
+// returned if decode return nil.
+// NOTE it only effected which method with bytes return.
+func WithErrorDecoder(decode func(status int, body []byte) error) Option {
+	return func(o *Options) {
+		o.checkStatus = true
+		o.errorDecoder = decode
+	}
+}
+
+// WithJSONError enable the status check and unmarshal the JSON body of the
+// unexpected statuses into v, v is returned as the error if it implements
+// the error, otherwise the StatusError is returned and v is filled.
+// NOTE it only effected which method with bytes return.
+func WithJSONError(v interface{}) Option {
+	return WithErrorDecoder(func(status int, body []byte) error {
+		if err := json.Unmarshal(body, v); err != nil {
+			return nil
+		}
+		if err, ok := v.(error); ok {
+			return err
+		}
+		return nil
+	})
+}
+
+// maxErrorBody is the max bytes of the body kept in StatusError.
+const maxErrorBody = 4 << 10
+
+// StatusError is the error of an unexpected response status,
+// use errors.As to inspect it.
+type StatusError struct {
+	StatusCode int
+	Method     string
+	// URL is the final URL of the request with the secrets redacted,
+	// see Config.RedactQuery.
+	URL string
+	// Header and Body are masked by the Config.Redaction,
+	// the Body is truncated to 4KB.
+	Header http.Header
+	Body   []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %q: http status code: %d", urlErrorOp(e.Method), e.URL, e.StatusCode)
+}
+
+// newStatusError return the StatusError of resp and its body data,
+// the sensitive data is masked by r.
+func newStatusError(resp *http.Response, data []byte, r Redaction) *StatusError {
+	data = redactJSON(data, r.JSONFields)
+	if len(data) > maxErrorBody {
+		data = data[:maxErrorBody]
+	}
+	e := &StatusError{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       data,
+	}
+	if len(r.Headers) > 0 {
+		e.Header = redactHeader(resp.Header, r.Headers, false)
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = redactURL(resp.Request.URL, r.Query)
+	}
+	return e
+}
+
+// checkStatus return the error of the response status of opts.
+func checkStatus(opts *Options, resp *http.Response, data []byte) error {
+	if !opts.checkStatus {
+		return nil
+	}
+	ok := resp.StatusCode >= 200 && resp.StatusCode < 300
+	if opts.expectStatus != nil {
+		ok = false
+		for _, code := range opts.expectStatus {
+			if resp.StatusCode == code {
+				ok = true
+				break
+			}
+		}
+	}
+	if ok {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return ErrNotModified
+	}
+	if opts.errorDecoder != nil {
+		if err := opts.errorDecoder(resp.StatusCode, data); err != nil {
+			return err
+		}
+	}
+	return newStatusError(resp, data, opts.redaction)
+}
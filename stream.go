@@ -0,0 +1,237 @@
+package xreq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// WithPostJSONStream set the JSON of v into the request body like
+// WithPostJSON, but v is encoded by a json.Encoder while the body is
+// being sent instead of being marshaled into the memory, for the very
+// large payloads. The body is sent chunked and re-encoded to replay.
+func WithPostJSONStream(v interface{}) Option {
+	return func(o *Options) {
+		encode := func(w io.Writer) error {
+			if err := json.NewEncoder(w).Encode(v); err != nil {
+				return fmt.Errorf("json encode error: %w", err)
+			}
+			return nil
+		}
+		o.Request.Header.Set("Content-Type", "application/json")
+		setStreamBody(o.Request, encode)
+		o.setBodyOption("WithPostJSONStream")
+	}
+}
+
+// WithMultipartFunc stream the multipart/form-data body written by fn,
+// which gets the full control of the multipart.Writer for the layouts
+// the other multipart options can't express. fn is invoked while the
+// body is being sent and again to replay it, the body is sent chunked
+// and the writer is closed after fn returns.
+//
+// Example:
+//
+// data, _, err := xreq.DoBytes(url,
+//     WithMultipartFunc(func(w *multipart.Writer) error {
+//         part, err := w.CreatePart(header)
+//         if err != nil {
+//             return err
+//         }
+//         _, err = io.Copy(part, r)
+//         return err
+//     }),
+// )
+func WithMultipartFunc(fn func(w *multipart.Writer) error) Option {
+	return func(o *Options) {
+		setMultipartStream(o.Request, func(w *multipart.Writer) error {
+			if err := fn(w); err != nil {
+				return fmt.Errorf("multipart func error: %w", err)
+			}
+			return nil
+		})
+		o.setBodyOption("WithMultipartFunc")
+	}
+}
+
+// WithMultipartFileStream stream the file of path from the disk as the
+// file part of fieldname with the fields of params, unlike the buffered
+// WithMultipartFile, the file is never loaded into the memory, the body
+// is sent chunked without the Content-Length and the file is reopened
+// to replay it.
+func WithMultipartFileStream(fieldname, path string, params ...map[string]string) Option {
+	return func(o *Options) {
+		if _, err := os.Stat(path); err != nil {
+			o.Err = fmt.Errorf("stat file error: %w", err)
+			return
+		}
+		setMultipartStream(o.Request, func(w *multipart.Writer) error {
+			if len(params) > 0 {
+				for k, v := range params[0] {
+					if err := w.WriteField(k, v); err != nil {
+						return fmt.Errorf("write field error: %w", err)
+					}
+				}
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("open file error: %w", err)
+			}
+			defer f.Close()
+			part, err := w.CreateFormFile(fieldname, filepath.Base(path))
+			if err != nil {
+				return fmt.Errorf("create form file error: %w", err)
+			}
+			if _, err = io.Copy(part, f); err != nil {
+				return fmt.Errorf("write form file error: %w", err)
+			}
+			return nil
+		})
+		o.setBodyOption("WithMultipartFileStream")
+	}
+}
+
+// setMultipartStream set the multipart/form-data body of req
+// written by fn while it's being sent, see setStreamBody.
+func setMultipartStream(req *http.Request, fn func(w *multipart.Writer) error) {
+	// the boundary is shared by the replays.
+	boundary := multipart.NewWriter(nil).Boundary()
+	write := func(w io.Writer) error {
+		mw := multipart.NewWriter(w)
+		if err := mw.SetBoundary(boundary); err != nil {
+			return fmt.Errorf("set boundary error: %w", err)
+		}
+		if err := fn(mw); err != nil {
+			return err
+		}
+		if err := mw.Close(); err != nil {
+			return fmt.Errorf("writer close error: %w", err)
+		}
+		return nil
+	}
+	req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+	setStreamBody(req, write)
+}
+
+// setStreamBody set the body of req written by write while it's
+// being sent, the body is sent chunked and written again to replay.
+func setStreamBody(req *http.Request, write func(w io.Writer) error) {
+	req.Method = http.MethodPost
+	req.Body = newPipeStream(write)
+	req.ContentLength = -1
+	req.GetBody = func() (io.ReadCloser, error) {
+		return newPipeStream(write), nil
+	}
+}
+
+// pipeStream runs write into a pipe in a goroutine,
+// which starts at the first read and exits once the
+// writing is done or the stream is closed.
+type pipeStream struct {
+	write func(w io.Writer) error
+	once  sync.Once
+	pr    *io.PipeReader
+	pw    *io.PipeWriter
+}
+
+func newPipeStream(write func(w io.Writer) error) *pipeStream {
+	pr, pw := io.Pipe()
+	return &pipeStream{write: write, pr: pr, pw: pw}
+}
+
+func (s *pipeStream) Read(p []byte) (int, error) {
+	s.once.Do(func() {
+		go func() {
+			s.pw.CloseWithError(s.write(s.pw))
+		}()
+	})
+	return s.pr.Read(p)
+}
+
+func (s *pipeStream) Close() error {
+	return s.pr.Close()
+}
+
+// WithReplayableBody buffers up to maxBuffer bytes of a body which
+// can't be replayed otherwise, so the retries and the 307/308 redirects
+// can replay it, they fail with the replay error if the body exceeds it
+// or it's not fully sent by the previous attempt.
+func WithReplayableBody(maxBuffer int64) Option {
+	return func(o *Options) {
+		o.replayBuffer = maxBuffer
+	}
+}
+
+// replayableBody tees the body of req into a buffer of max bytes
+// for its GetBody unless it's replayable already.
+func replayableBody(req *http.Request, max int64) {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return
+	}
+	rb := &replayBuffer{r: req.Body, max: max}
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{rb, req.Body}
+	req.GetBody = rb.replay
+}
+
+// replayBuffer keeps the bytes read from r until they exceed max,
+// it's read by the transport and replayed by GetBody concurrently.
+type replayBuffer struct {
+	r        io.Reader
+	mu       sync.Mutex
+	buf      []byte
+	max      int64
+	overflow bool
+	eof      bool
+}
+
+func (b *replayBuffer) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.overflow {
+		if int64(len(b.buf)+n) > b.max {
+			b.overflow = true
+			b.buf = nil
+		} else {
+			b.buf = append(b.buf, p[:n]...)
+		}
+	}
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+// replay return the buffered body, the original body is closed
+// once the attempt ends, so only a fully read body can be replayed.
+func (b *replayBuffer) replay() (io.ReadCloser, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.overflow {
+		return nil, fmt.Errorf("replay body error: body exceeds %d bytes", b.max)
+	}
+	if !b.eof {
+		return nil, errors.New("replay body error: body is not fully read")
+	}
+	return ioutil.NopCloser(bytes.NewReader(b.buf)), nil
+}
+
+// WithChunked send the request body with the chunked transfer encoding,
+// which is for the streaming body of unknown length, since a reader
+// setBody can't measure is sent with ContentLength 0 silently.
+func WithChunked() Option {
+	return func(o *Options) {
+		o.chunked = true
+	}
+}
@@ -0,0 +1,92 @@
+package xreq
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// TraceContext is the W3C trace context of a request,
+// see https://www.w3.org/TR/trace-context/.
+type TraceContext struct {
+	// TraceParent is "version-traceid-parentid-flags",
+	// e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+	TraceParent string
+	TraceState  string
+}
+
+type traceContextKey struct{}
+
+// ContextWithTraceContext return a copy of ctx carrying the trace context,
+// e.g. the one received by an HTTP server.
+func ContextWithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceContextFromContext return the trace context carried by ctx.
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// WithTraceContext set the traceparent and tracestate headers,
+// the request continues the trace carried by the context
+// (see ContextWithTraceContext) with a new span ID, or starts a new trace.
+// It's a lightweight way to propagate the distributed tracing headers
+// without the OpenTelemetry SDK, it overrides the Config.TraceContext.
+func WithTraceContext(enable bool) Option {
+	return func(o *Options) {
+		o.traceContext = enable
+	}
+}
+
+// setTraceContext set the trace context headers into req
+// and return the traceparent.
+func setTraceContext(req *http.Request) string {
+	tc, _ := TraceContextFromContext(req.Context())
+	traceID, flags := parseTraceParent(tc.TraceParent)
+	if traceID == "" {
+		traceID, flags = randomHex(16), "01"
+		tc.TraceState = ""
+	}
+
+	parent := "00-" + traceID + "-" + randomHex(8) + "-" + flags
+	req.Header.Set("traceparent", parent)
+	if tc.TraceState != "" {
+		req.Header.Set("tracestate", tc.TraceState)
+	}
+	return parent
+}
+
+// parseTraceParent return the trace ID and flags of a valid traceparent.
+func parseTraceParent(parent string) (traceID, flags string) {
+	parts := strings.Split(parent, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		!isHex(parts[1], 32) || !isHex(parts[2], 16) || !isHex(parts[3], 2) {
+		return "", ""
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", ""
+	}
+	return parts[1], parts[3]
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
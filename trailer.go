@@ -0,0 +1,30 @@
+package xreq
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// WithExpectTrailers declare the trailers expected after the response body,
+// it sends "TE: trailers" to tell the server the client accepts trailers.
+// The received trailers can be read from Result.Trailer.
+// NOTE the existence of the trailers is only checked by the methods with
+// bytes return, since they read the body to EOF.
+func WithExpectTrailers(names ...string) Option {
+	return func(o *Options) {
+		o.Request.Header.Set("TE", "trailers")
+		o.trailers = append(o.trailers, names...)
+	}
+}
+
+// checkTrailers return an error if any of the expected trailers is missing,
+// it must be called after the body has been read to EOF.
+func checkTrailers(resp *http.Response, names []string) error {
+	for _, name := range names {
+		// a declared trailer is pre-filled with nil until it's received.
+		if len(resp.Trailer.Values(name)) == 0 {
+			return fmt.Errorf("missing trailer: %s", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package xreq
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	urlpkg "net/url"
+	"time"
+)
+
+// WithExpectContinue set the "Expect: 100-continue" header,
+// the request body will be sent after the server responds 100 Continue
+// or the timeout elapsed, it's useful for large uploads
+// against servers that may reject the request early.
+// NOTE it requires the Config.Transport to be a *http.Transport.
+func WithExpectContinue(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Request.Header.Set("Expect", "100-continue")
+		o.expectContinue = true
+		o.expectTimeout = timeout
+	}
+}
+
+// httpClient return the http.Client to send the request with opts,
+// it's a shallow copy of c.hc with the redirect policy of opts
+// and the customized transport if the request needs it.
+func (c *Client) httpClient(opts *Options) (*http.Client, error) {
+	hc := *c.hc
+	hc.CheckRedirect = checkRedirect(opts)
+	if opts.noCookies {
+		hc.Jar = nil
+	} else if opts.jar != nil {
+		hc.Jar = opts.jar
+	}
+	if opts.expectContinue {
+		t, err := c.expectContinueTransport(opts.expectTimeout)
+		if err != nil {
+			return nil, err
+		}
+		hc.Transport = t
+	}
+	if debug.Load() {
+		hc.Transport = &debugTransport{base: hc.Transport, redaction: opts.redaction}
+	}
+	return &hc, nil
+}
+
+// expectContinueTransport return a clone of the client transport
+// with the ExpectContinueTimeout, the clones are cached by timeout
+// so they share the connection pool between requests.
+func (c *Client) expectContinueTransport(timeout time.Duration) (http.RoundTripper, error) {
+	if t, ok := c.transports.Load(timeout); ok {
+		return t.(http.RoundTripper), nil
+	}
+
+	base := c.config.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	bt, ok := base.(*http.Transport)
+	if !ok {
+		return nil, errors.New("expect continue requires *http.Transport")
+	}
+	t := bt.Clone()
+	t.ExpectContinueTimeout = timeout
+	actual, _ := c.transports.LoadOrStore(timeout, wrapTransport(c.config, t))
+	return actual.(http.RoundTripper), nil
+}
+
+// tuneTransport return the Transport of conf with the timeouts of conf.
+func tuneTransport(conf Config) http.RoundTripper {
+	if conf.DialTimeout <= 0 && conf.TLSHandshakeTimeout <= 0 &&
+		conf.ResponseHeaderTimeout <= 0 && conf.IdleConnTimeout <= 0 {
+		return conf.Transport
+	}
+	base := conf.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	bt, ok := base.(*http.Transport)
+	if !ok {
+		return conf.Transport
+	}
+
+	t := bt.Clone()
+	if conf.DialTimeout > 0 {
+		t.DialContext = (&net.Dialer{
+			Timeout:   conf.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	}
+	if conf.TLSHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = conf.TLSHandshakeTimeout
+	}
+	if conf.ResponseHeaderTimeout > 0 {
+		t.ResponseHeaderTimeout = conf.ResponseHeaderTimeout
+	}
+	if conf.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = conf.IdleConnTimeout
+	}
+	return t
+}
+
+// wrapTransport wraps the base transport with the layers enabled by conf,
+// it return base itself if no layer is enabled.
+func wrapTransport(conf Config, base http.RoundTripper) http.RoundTripper {
+	if conf.ETagCache != nil {
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		base = &cacheTransport{base: base, cache: conf.ETagCache, etagOnly: true}
+	}
+	if conf.Cache != nil {
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		base = &cacheTransport{base: base, cache: conf.Cache}
+	}
+	return base
+}
+
+// closeIdleConnections closes the idle connections of t if it supports it.
+func closeIdleConnections(t http.RoundTripper) {
+	if ci, ok := t.(interface{ CloseIdleConnections() }); ok {
+		ci.CloseIdleConnections()
+	}
+}
+
+// TLSFiles are the PEM files of the TLS configuration.
+type TLSFiles struct {
+	// CAFile is the CA certificates to verify the servers,
+	// default the system ones.
+	CAFile string `json:"ca_file"`
+	// CertFile and KeyFile are the client certificate.
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+	// InsecureSkipVerify skips the verification of the servers.
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+}
+
+// newTransport return a clone of the http.DefaultTransport with the proxy
+// and the TLS of files, it return nil if neither is set.
+func newTransport(proxy string, files TLSFiles) (http.RoundTripper, error) {
+	if proxy == "" && files == (TLSFiles{}) {
+		return nil, nil
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != "" {
+		u, err := urlpkg.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("parse proxy error: %w", err)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+	if files == (TLSFiles{}) {
+		return t, nil
+	}
+
+	conf := &tls.Config{InsecureSkipVerify: files.InsecureSkipVerify}
+	if files.CAFile != "" {
+		data, err := ioutil.ReadFile(files.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file error: %w", err)
+		}
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificate in ca file %s", files.CAFile)
+		}
+	}
+	if files.CertFile != "" || files.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load key pair error: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	t.TLSClientConfig = conf
+	return t, nil
+}
@@ -0,0 +1,27 @@
+package xreq
+
+// DoXML method construct a HTTP request with options
+// and unmarshal the XML body into out, see Client.DoXML.
+func DoXML(url string, out interface{}, opt ...Option) error {
+	return defaultClient.DoXML(url, out, opt...)
+}
+
+// DoXML method construct a HTTP request with options and unmarshal
+// the XML body into out, the status is checked as WithCheckStatus(true)
+// unless opt overrides it, the body of the failed status is kept
+// in the StatusError or decoded by WithErrorDecoder.
+//
+// Example:
+//
+// var v Payment
+// err := xreq.DoXML("http://localhost/payment", &v,
+//     WithAccept("application/xml"),
+// )
+func (c *Client) DoXML(url string, out interface{}, opt ...Option) error {
+	opt = append([]Option{WithCheckStatus(true)}, opt...)
+	resp, err := c.DoR(url, opt...)
+	if err != nil {
+		return err
+	}
+	return resp.XML(out)
+}